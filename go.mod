@@ -5,4 +5,5 @@ go 1.22.2
 require (
 	github.com/blang/semver v3.5.1+incompatible
 	github.com/inconshreveable/go-update v0.0.0-20160112193335-8152e7eb6ccf
+	github.com/ulikunitz/xz v0.5.17
 )
@@ -0,0 +1,223 @@
+package selfupdate
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"bufio"
+	"bytes"
+	"compress/bzip2"
+	"compress/gzip"
+	"errors"
+	"fmt"
+	"io"
+	"path"
+	"runtime"
+	"strings"
+	"sync"
+
+	"github.com/ulikunitz/xz"
+)
+
+type (
+	// Decompressor wraps a compressed stream, e.g. "zst", into a reader of
+	// the decompressed data.
+	Decompressor func(r io.Reader) (io.Reader, error)
+
+	archiveFormat struct {
+		compression string
+		tar         bool
+		zip         bool
+	}
+)
+
+var (
+	decompressorsMu sync.RWMutex
+	decompressors   = map[string]Decompressor{
+		"gz": func(r io.Reader) (io.Reader, error) {
+			return gzip.NewReader(r)
+		},
+		"bz2": func(r io.Reader) (io.Reader, error) {
+			return bzip2.NewReader(r), nil
+		},
+		"xz": func(r io.Reader) (io.Reader, error) {
+			return xz.NewReader(r)
+		},
+	}
+
+	archiveSuffixes = []struct {
+		suffix string
+		format archiveFormat
+	}{
+		{".tar.gz", archiveFormat{compression: "gz", tar: true}},
+		{".tgz", archiveFormat{compression: "gz", tar: true}},
+		{".tar.xz", archiveFormat{compression: "xz", tar: true}},
+		{".txz", archiveFormat{compression: "xz", tar: true}},
+		{".tar.bz2", archiveFormat{compression: "bz2", tar: true}},
+		{".tbz2", archiveFormat{compression: "bz2", tar: true}},
+		{".tbz", archiveFormat{compression: "bz2", tar: true}},
+		{".tar", archiveFormat{tar: true}},
+		{".zip", archiveFormat{zip: true}},
+		{".gz", archiveFormat{compression: "gz"}},
+		{".xz", archiveFormat{compression: "xz"}},
+		{".bz2", archiveFormat{compression: "bz2"}},
+	}
+
+	compressionMagic = []struct {
+		magic       []byte
+		compression string
+	}{
+		{[]byte{0x1f, 0x8b}, "gz"},
+		{[]byte{0xfd, '7', 'z', 'X', 'Z', 0x00}, "xz"},
+		{[]byte("BZh"), "bz2"},
+	}
+)
+
+// RegisterDecompressor makes a compression format available to archive
+// extraction or replaces a built-in one. "gz", "bz2" and "xz" are built in.
+func RegisterDecompressor(format string, d Decompressor) {
+	decompressorsMu.Lock()
+	defer decompressorsMu.Unlock()
+
+	decompressors[format] = d
+}
+
+func (f archiveFormat) isArchive() bool {
+	return f.compression != "" || f.tar || f.zip
+}
+
+// detectArchive guesses the format from the asset name and falls back to the
+// magic bytes when the name has no known extension.
+func detectArchive(name string, data []byte) (format archiveFormat, fromName bool) {
+	lower := strings.ToLower(name)
+	for _, s := range archiveSuffixes {
+		if strings.HasSuffix(lower, s.suffix) {
+			return s.format, true
+		}
+	}
+
+	if bytes.HasPrefix(data, []byte("PK\x03\x04")) {
+		return archiveFormat{zip: true}, false
+	}
+
+	for _, m := range compressionMagic {
+		if bytes.HasPrefix(data, m.magic) {
+			return archiveFormat{compression: m.compression}, false
+		}
+	}
+
+	return archiveFormat{tar: isTar(data)}, false
+}
+
+func isTar(head []byte) bool {
+	return len(head) >= 262 && string(head[257:262]) == "ustar"
+}
+
+// extract returns a reader of the executable stored in the asset of the
+// given size. Assets that are not archives are returned as is.
+func extract(assetName string, asset io.ReaderAt, size int64, executable string) (io.Reader, string, error) {
+	head := make([]byte, min(size, 512))
+	if _, err := asset.ReadAt(head, 0); err != nil && !errors.Is(err, io.EOF) {
+		return nil, "", fmt.Errorf("failed to read %s: %w", assetName, err)
+	}
+
+	var r io.Reader = io.NewSectionReader(asset, 0, size)
+
+	format, fromName := detectArchive(assetName, head)
+	if !format.isArchive() {
+		return r, assetName, nil
+	}
+
+	if format.zip {
+		return extractZip(asset, size, executable)
+	}
+
+	if format.compression != "" {
+		decompressorsMu.RLock()
+		decompress, ok := decompressors[format.compression]
+		decompressorsMu.RUnlock()
+
+		if !ok {
+			return nil, "", fmt.Errorf("%w: no decompressor registered for %q", ErrUnsupportedArchive, format.compression)
+		}
+
+		var err error
+		if r, err = decompress(r); err != nil {
+			return nil, "", fmt.Errorf("failed to decompress %s: %w", assetName, err)
+		}
+	}
+
+	if !fromName {
+		br := bufio.NewReaderSize(r, 512)
+		head, _ := br.Peek(262)
+		format.tar = isTar(head)
+		r = br
+	}
+
+	if !format.tar {
+		return r, strings.TrimSuffix(assetName, path.Ext(assetName)), nil
+	}
+
+	return extractTar(r, executable)
+}
+
+func extractTar(r io.Reader, executable string) (io.Reader, string, error) {
+	tr := tar.NewReader(r)
+
+	for {
+		hdr, err := tr.Next()
+		if errors.Is(err, io.EOF) {
+			return nil, "", fmt.Errorf("%w: %s", ErrExecutableNotFound, executable)
+		}
+		if err != nil {
+			return nil, "", fmt.Errorf("failed to read tar archive: %w", err)
+		}
+
+		if hdr.Typeflag == tar.TypeReg && matchExecutable(hdr.Name, executable) {
+			return tr, hdr.Name, nil
+		}
+	}
+}
+
+func extractZip(asset io.ReaderAt, size int64, executable string) (io.Reader, string, error) {
+	zr, err := zip.NewReader(asset, size)
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to read zip archive: %w", err)
+	}
+
+	for _, f := range zr.File {
+		if f.FileInfo().IsDir() || !matchExecutable(f.Name, executable) {
+			continue
+		}
+
+		rc, err := f.Open()
+		if err != nil {
+			return nil, "", fmt.Errorf("failed to open %s: %w", f.Name, err)
+		}
+
+		return rc, f.Name, nil
+	}
+
+	return nil, "", fmt.Errorf("%w: %s", ErrExecutableNotFound, executable)
+}
+
+// matchExecutable reports whether the archive entry is the executable. The
+// executable path may omit leading directories of the entry, so "bin/tool"
+// matches "tool_1.0_linux_amd64/bin/tool". On Windows the ".exe" extension
+// is optional.
+func matchExecutable(entry, executable string) bool {
+	entry = strings.TrimPrefix(path.Clean("/"+entry), "/")
+	executable = strings.TrimPrefix(path.Clean("/"+executable), "/")
+
+	candidates := []string{executable}
+	if runtime.GOOS == "windows" && !strings.HasSuffix(executable, ".exe") {
+		candidates = append(candidates, executable+".exe")
+	}
+
+	for _, c := range candidates {
+		if entry == c || strings.HasSuffix(entry, "/"+c) {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,189 @@
+package selfupdate
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"bytes"
+	"compress/gzip"
+	"errors"
+	"io"
+	"testing"
+
+	"github.com/ulikunitz/xz"
+)
+
+func makeTar(t *testing.T, files map[string]string) []byte {
+	t.Helper()
+
+	var buf bytes.Buffer
+	tw := tar.NewWriter(&buf)
+	for name, content := range files {
+		hdr := &tar.Header{Name: name, Mode: 0o755, Size: int64(len(content)), Typeflag: tar.TypeReg}
+		if err := tw.WriteHeader(hdr); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := tw.Write([]byte(content)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := tw.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	return buf.Bytes()
+}
+
+func makeZip(t *testing.T, files map[string]string) []byte {
+	t.Helper()
+
+	var buf bytes.Buffer
+	zw := zip.NewWriter(&buf)
+	for name, content := range files {
+		w, err := zw.Create(name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err := w.Write([]byte(content)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := zw.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	return buf.Bytes()
+}
+
+func gzipData(t *testing.T, data []byte) []byte {
+	t.Helper()
+
+	var buf bytes.Buffer
+	zw := gzip.NewWriter(&buf)
+	if _, err := zw.Write(data); err != nil {
+		t.Fatal(err)
+	}
+	if err := zw.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	return buf.Bytes()
+}
+
+func xzData(t *testing.T, data []byte) []byte {
+	t.Helper()
+
+	var buf bytes.Buffer
+	xw, err := xz.NewWriter(&buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := xw.Write(data); err != nil {
+		t.Fatal(err)
+	}
+	if err := xw.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	return buf.Bytes()
+}
+
+func Test_extract(t *testing.T) {
+	files := map[string]string{
+		"test_1.0.0_linux_amd64/README.md": "readme",
+		"test_1.0.0_linux_amd64/bin/test":  "binary",
+	}
+
+	tests := []struct {
+		name       string
+		asset      string
+		data       []byte
+		executable string
+		want       string
+		wantErr    error
+	}{
+		{
+			name:       "raw binary",
+			asset:      "test-linux-amd64",
+			data:       []byte("binary"),
+			executable: "test",
+			want:       "binary",
+		},
+		{
+			name:       "tar.gz by name",
+			asset:      "test_linux_amd64.tar.gz",
+			data:       gzipData(t, makeTar(t, files)),
+			executable: "test",
+			want:       "binary",
+		},
+		{
+			name:       "tar.gz with nested path",
+			asset:      "test_linux_amd64.tgz",
+			data:       gzipData(t, makeTar(t, files)),
+			executable: "bin/test",
+			want:       "binary",
+		},
+		{
+			name:       "tar.gz by magic bytes",
+			asset:      "test_linux_amd64",
+			data:       gzipData(t, makeTar(t, files)),
+			executable: "test",
+			want:       "binary",
+		},
+		{
+			name:       "zip",
+			asset:      "test_linux_amd64.zip",
+			data:       makeZip(t, files),
+			executable: "test",
+			want:       "binary",
+		},
+		{
+			name:       "single file gz",
+			asset:      "test_linux_amd64.gz",
+			data:       gzipData(t, []byte("binary")),
+			executable: "test",
+			want:       "binary",
+		},
+		{
+			name:       "missing executable",
+			asset:      "test_linux_amd64.tar.gz",
+			data:       gzipData(t, makeTar(t, files)),
+			executable: "other",
+			wantErr:    ErrExecutableNotFound,
+		},
+		{
+			name:       "tar.xz",
+			asset:      "test_linux_amd64.tar.xz",
+			data:       xzData(t, makeTar(t, files)),
+			executable: "test",
+			want:       "binary",
+		},
+		{
+			name:       "single file xz by magic bytes",
+			asset:      "test_linux_amd64",
+			data:       xzData(t, []byte("binary")),
+			executable: "test",
+			want:       "binary",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r, _, err := extract(tt.asset, bytes.NewReader(tt.data), int64(len(tt.data)), tt.executable)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("extract() error = %v, wantErr %v", err, tt.wantErr)
+			}
+
+			if tt.wantErr != nil {
+				return
+			}
+
+			got, err := io.ReadAll(r)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if string(got) != tt.want {
+				t.Errorf("extract() got = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,169 @@
+package selfupdate
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net/http"
+	"path"
+	"strings"
+
+	"github.com/aatumaykin/go-self-update/selfupdate/release"
+)
+
+type (
+	Checksum struct {
+		// Templates are the names of the checksums asset tried in order.
+		// Besides the Filter values, templates can use {{.Asset}} (the
+		// matched asset name) and {{.Tag}} (the release tag).
+		Templates []string
+		// Required makes UpdateTo fail when no checksum is found for the asset.
+		Required bool
+	}
+
+	ChecksumMismatchError struct {
+		Asset    string
+		Expected []byte
+		Actual   []byte
+	}
+)
+
+var defaultChecksumTemplates = []string{
+	"checksums.txt",
+	"SHA256SUMS",
+	"{{.Asset}}.sha256",
+}
+
+func (e *ChecksumMismatchError) Error() string {
+	return fmt.Sprintf("checksum mismatch for %s: expected %x, got %x", e.Asset, e.Expected, e.Actual)
+}
+
+func (e *ChecksumMismatchError) Is(target error) bool {
+	return target == ErrChecksumMismatch
+}
+
+// findChecksumAsset returns the first checksums asset of the release that
+// matches one of the configured templates.
+func (u *Updater) findChecksumAsset(r release.Release, rel *Release) (release.Asset, error) {
+	data := u.assetValues(rel)
+
+	names := make([]string, 0, len(u.checksum.Templates))
+	for _, text := range u.checksum.Templates {
+		name, err := renderTemplate(text, data)
+		if err != nil {
+			return nil, err
+		}
+
+		names = append(names, name)
+	}
+
+	return findNamedAsset(r, names...), nil
+}
+
+// findNamedAsset returns the asset listed with the release under the first
+// of names. Only if none is listed, the provider is asked, since it may
+// resolve any name, e.g. to a GitLab generic package that does not exist.
+func findNamedAsset(r release.Release, names ...string) release.Asset {
+	assets := r.GetAssets()
+
+	for _, name := range names {
+		for _, asset := range assets {
+			if asset.GetName() == name {
+				return asset
+			}
+		}
+	}
+
+	for _, name := range names {
+		if asset, found := r.FindAsset(name); found {
+			return asset
+		}
+	}
+
+	return nil
+}
+
+// fetchChecksum downloads the checksums asset of rel and returns the SHA-256
+// digest of the release asset. A nil digest means there is nothing to verify.
+func (u *Updater) fetchChecksum(ctx context.Context, rel *Release) ([]byte, error) {
+	if rel.Checksum != nil {
+		return rel.Checksum, nil
+	}
+
+	if rel.ChecksumURL == "" {
+		if u.checksum.Required {
+			return nil, fmt.Errorf("%w: no checksums asset for %s", ErrChecksumMissing, rel.AssetName)
+		}
+
+		return nil, nil
+	}
+
+	data, err := u.fetch(ctx, rel.ChecksumURL)
+
+	// A listed but missing checksums asset, e.g. a GitLab generic package
+	// fallback URL, is treated like no checksums asset at all.
+	var statusErr *HTTPStatusError
+	if errors.As(err, &statusErr) && statusErr.StatusCode == http.StatusNotFound {
+		if u.checksum.Required {
+			return nil, fmt.Errorf("%w: %w", ErrChecksumMissing, err)
+		}
+
+		u.logger.WarnContext(ctx, "Checksums asset not found, skipping verification", "url", rel.ChecksumURL)
+		return nil, nil
+	}
+
+	if err != nil {
+		return nil, fmt.Errorf("failed to download checksums: %w", err)
+	}
+
+	digest, err := parseChecksums(data, rel.AssetName)
+	if err != nil {
+		return nil, err
+	}
+
+	if digest == nil {
+		return nil, fmt.Errorf("%w: %s is not listed in %s", ErrChecksumMissing, rel.AssetName, rel.ChecksumURL)
+	}
+
+	return digest, nil
+}
+
+// parseChecksums looks up name in a sha256sum ("<hex>  <name>"), BSD
+// ("SHA256 (<name>) = <hex>") or single digest (".sha256" file) listing.
+func parseChecksums(data []byte, name string) ([]byte, error) {
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		var sum, file string
+
+		if rest, ok := strings.CutPrefix(line, "SHA256 ("); ok {
+			file, sum, _ = strings.Cut(rest, ") = ")
+		} else {
+			fields := strings.Fields(line)
+			sum = fields[0]
+			if len(fields) > 1 {
+				file = strings.TrimPrefix(fields[1], "*")
+			}
+		}
+
+		if file != "" && file != name && path.Base(file) != name {
+			continue
+		}
+
+		digest, err := hex.DecodeString(sum)
+		if err != nil || len(digest) != 32 {
+			return nil, fmt.Errorf("invalid SHA-256 checksum for %s: %q", name, sum)
+		}
+
+		return digest, nil
+	}
+
+	return nil, scanner.Err()
+}
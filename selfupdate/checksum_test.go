@@ -0,0 +1,166 @@
+package selfupdate
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/inconshreveable/go-update"
+)
+
+func Test_parseChecksums(t *testing.T) {
+	sum := sha256.Sum256([]byte("binary"))
+	hexSum := hex.EncodeToString(sum[:])
+
+	tests := []struct {
+		name    string
+		data    string
+		asset   string
+		want    string
+		wantErr bool
+	}{
+		{
+			name:  "sha256sum format",
+			data:  "0000000000000000000000000000000000000000000000000000000000000000  other\n" + hexSum + "  test-linux-amd64\n",
+			asset: "test-linux-amd64",
+			want:  hexSum,
+		},
+		{
+			name:  "binary mode marker",
+			data:  hexSum + " *test-linux-amd64\n",
+			asset: "test-linux-amd64",
+			want:  hexSum,
+		},
+		{
+			name:  "BSD format",
+			data:  "SHA256 (test-linux-amd64) = " + hexSum + "\n",
+			asset: "test-linux-amd64",
+			want:  hexSum,
+		},
+		{
+			name:  "single digest",
+			data:  hexSum + "\n",
+			asset: "test-linux-amd64",
+			want:  hexSum,
+		},
+		{
+			name:  "asset not listed",
+			data:  hexSum + "  other\n",
+			asset: "test-linux-amd64",
+		},
+		{
+			name:    "invalid digest",
+			data:    "xyz  test-linux-amd64\n",
+			asset:   "test-linux-amd64",
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := parseChecksums([]byte(tt.data), tt.asset)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("parseChecksums() error = %v, wantErr %v", err, tt.wantErr)
+			}
+
+			if hex.EncodeToString(got) != tt.want {
+				t.Errorf("parseChecksums() got = %x, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestUpdater_UpdateTo_Checksum(t *testing.T) {
+	binary := []byte("new binary")
+	sum := sha256.Sum256(binary)
+
+	tests := []struct {
+		name      string
+		checksums string
+		required  bool
+		noURL     bool
+		// callerSum is passed in update.Options.Checksum.
+		callerSum []byte
+		wantErr   error
+		wantFail  bool
+	}{
+		{
+			name:      "valid checksum should apply update",
+			checksums: hex.EncodeToString(sum[:]) + "  test\n",
+		},
+		{
+			name:      "wrong checksum should fail",
+			checksums: hex.EncodeToString(make([]byte, 32)) + "  test\n",
+			wantErr:   ErrChecksumMismatch,
+		},
+		{
+			name:      "asset missing from checksums should fail",
+			checksums: hex.EncodeToString(sum[:]) + "  other\n",
+			wantErr:   ErrChecksumMissing,
+		},
+		{
+			name:     "required checksum without checksums asset should fail",
+			required: true,
+			noURL:    true,
+			wantErr:  ErrChecksumMissing,
+		},
+		{
+			name:  "missing optional checksum should apply update",
+			noURL: true,
+		},
+		{
+			name:      "caller checksum should be kept without checksums asset",
+			noURL:     true,
+			callerSum: make([]byte, 32),
+			wantFail:  true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mux := http.NewServeMux()
+			mux.HandleFunc("/test", func(w http.ResponseWriter, r *http.Request) {
+				_, _ = w.Write(binary)
+			})
+			mux.HandleFunc("/checksums.txt", func(w http.ResponseWriter, r *http.Request) {
+				_, _ = w.Write([]byte(tt.checksums))
+			})
+			srv := httptest.NewServer(mux)
+			defer srv.Close()
+
+			target := filepath.Join(t.TempDir(), "test")
+			if err := os.WriteFile(target, []byte("old binary"), 0o755); err != nil {
+				t.Fatal(err)
+			}
+
+			rel := &Release{AssetName: "test", AssetURL: srv.URL + "/test"}
+			if !tt.noURL {
+				rel.ChecksumURL = srv.URL + "/checksums.txt"
+			}
+
+			u, _ := New(Config{Checksum: &Checksum{Required: tt.required}})
+			err := u.UpdateTo(context.Background(), rel, &update.Options{TargetPath: target, Checksum: tt.callerSum})
+			if tt.wantFail {
+				if err == nil {
+					t.Fatal("UpdateTo() error = nil, want error")
+				}
+			} else if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("UpdateTo() error = %v, wantErr %v", err, tt.wantErr)
+			}
+
+			want := "new binary"
+			if tt.wantErr != nil || tt.wantFail {
+				want = "old binary"
+			}
+
+			got, _ := os.ReadFile(target)
+			if string(got) != want {
+				t.Errorf("UpdateTo() target = %q, want %q", got, want)
+			}
+		})
+	}
+}
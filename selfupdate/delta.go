@@ -0,0 +1,122 @@
+package selfupdate
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"errors"
+	"fmt"
+	"os"
+
+	"github.com/aatumaykin/go-self-update/selfupdate/release"
+	"github.com/inconshreveable/go-update"
+)
+
+// Delta enables binary patch updates: instead of the full asset, a bsdiff
+// patch from the running version is downloaded and applied to the current
+// executable. Patches are only used for assets that are the bare executable
+// with a known SHA-256 checksum, which the patched file must match.
+// Otherwise, or if the patch fails, the full asset is downloaded.
+type Delta struct {
+	// Template names the patch asset. Besides the Filter values it can use
+	// {{.From}} (the running version) and {{.To}} (the release version).
+	Template string
+}
+
+const defaultDeltaTemplate = "{{.Name}}-{{.From}}-to-{{.To}}-{{.OS}}-{{.Arch}}.patch"
+
+func newDelta(delta *Delta) *Delta {
+	if delta == nil || delta.Template != "" {
+		return delta
+	}
+
+	return &Delta{Template: defaultDeltaTemplate}
+}
+
+// findPatchAsset returns the patch from the running version to rel, or nil
+// if the release has none.
+func (u *Updater) findPatchAsset(r release.Release, rel *Release) (release.Asset, error) {
+	current, ok := u.CurrentVersion()
+	if !ok || !rel.Version.GT(current) {
+		return nil, nil
+	}
+
+	values := u.assetValues(rel)
+	values["From"] = current.String()
+	values["To"] = rel.Version.String()
+
+	rule := FilterRule{Template: u.delta.Template}
+	oses, arches := platformCandidates(u.filter.Values)
+	assets := r.GetAssets()
+
+	for _, goos := range oses {
+		for _, arch := range arches {
+			values["OS"], values["Arch"] = goos, arch
+
+			match, _, err := rule.matcher(values)
+			if err != nil {
+				return nil, err
+			}
+
+			for _, asset := range assets {
+				if match(asset.GetName()) {
+					return asset, nil
+				}
+			}
+		}
+	}
+
+	return nil, nil
+}
+
+// patch returns the asset of rel rebuilt from the executable at target and
+// the release patch, or nil if the full asset has to be downloaded.
+func (u *Updater) patch(ctx context.Context, rel *Release, target string, checksum []byte) []byte {
+	if rel.PatchURL == "" {
+		return nil
+	}
+
+	data, err := u.applyPatch(ctx, rel, target, checksum)
+	if err != nil {
+		u.logger.WarnContext(ctx, "Patch not applied, downloading full asset", "patch", rel.PatchURL, "error", err)
+		return nil
+	}
+
+	u.logger.InfoContext(ctx, "Patch applied", "patch", rel.PatchURL)
+
+	return data
+}
+
+func (u *Updater) applyPatch(ctx context.Context, rel *Release, target string, checksum []byte) ([]byte, error) {
+	if checksum == nil {
+		return nil, errors.New("no checksum to verify the patched executable")
+	}
+
+	if _, fromName := detectArchive(rel.AssetName, nil); fromName {
+		return nil, fmt.Errorf("asset %s is an archive", rel.AssetName)
+	}
+
+	old, err := os.ReadFile(target)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read executable: %w", err)
+	}
+
+	u.logger.InfoContext(ctx, "Downloading patch", "url", rel.PatchURL)
+
+	patch, err := u.fetch(ctx, rel.PatchURL)
+	if err != nil {
+		return nil, fmt.Errorf("failed to download patch: %w", err)
+	}
+
+	var buf bytes.Buffer
+	if err := update.NewBSDiffPatcher().Patch(bytes.NewReader(old), &buf, bytes.NewReader(patch)); err != nil {
+		return nil, fmt.Errorf("failed to apply patch: %w", err)
+	}
+
+	actual := sha256.Sum256(buf.Bytes())
+	if !bytes.Equal(actual[:], checksum) {
+		return nil, &ChecksumMismatchError{Asset: rel.AssetName, Expected: checksum, Actual: actual[:]}
+	}
+
+	return buf.Bytes(), nil
+}
@@ -0,0 +1,121 @@
+package selfupdate
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/inconshreveable/go-update"
+)
+
+// bsdiff patch from "old binary" to "new binary".
+const testPatch = "QlNESUZGNDApAAAAAAAAAC0AAAAAAAAACgAAAAAAAABCWmg5MUFZJlNZFfJuXwAAAmAAQBAIACAAMMwM9QXOLuSKcKEgK+TcvkJaaDkxQVkmU1nwdkV9AAAD4ADIAAgAACCgACGDQZoLEsHF3JFOFCQ8HZFfQEJaaDkXckU4UJAAAAAA"
+
+func TestUpdater_UpdateTo_Delta(t *testing.T) {
+	binary := []byte("new binary")
+	digest := sha256.Sum256(binary)
+
+	patch, err := base64.StdEncoding.DecodeString(testPatch)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name          string
+		installed     string
+		patchAsset    bool
+		wantPatchURL  bool
+		wantDownloads int
+	}{
+		{
+			name:         "patch applied",
+			installed:    "old binary",
+			patchAsset:   true,
+			wantPatchURL: true,
+		},
+		{
+			name:          "patch for another executable falls back to full asset",
+			installed:     "odd binary",
+			patchAsset:    true,
+			wantPatchURL:  true,
+			wantDownloads: 1,
+		},
+		{
+			name:          "no patch asset",
+			installed:     "old binary",
+			wantDownloads: 1,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var downloads int
+
+			assets := `{"name": "test-linux-amd64", "url": "test-linux-amd64", "sha256": "` + hex.EncodeToString(digest[:]) + `"}`
+			if tt.patchAsset {
+				assets += `, {"name": "test-1.0.0-to-1.1.0-linux-amd64.patch", "url": "test.patch"}`
+			}
+
+			mux := http.NewServeMux()
+			mux.HandleFunc("/manifest.json", func(w http.ResponseWriter, r *http.Request) {
+				_, _ = w.Write([]byte(`{"releases": [{"version": "1.1.0", "assets": [` + assets + `]}]}`))
+			})
+			mux.HandleFunc("/test-linux-amd64", func(w http.ResponseWriter, r *http.Request) {
+				downloads++
+				_, _ = w.Write(binary)
+			})
+			mux.HandleFunc("/test.patch", func(w http.ResponseWriter, r *http.Request) {
+				_, _ = w.Write(patch)
+			})
+			srv := httptest.NewServer(mux)
+			defer srv.Close()
+
+			u, err := New(Config{
+				RepositoryType: Manifest,
+				APIBaseURL:     srv.URL,
+				CurrentVersion: "1.0.0",
+				StagingDir:     t.TempDir(),
+				Delta:          &Delta{},
+				Filter: &Filter{
+					Template: "{{.Name}}-{{.OS}}-{{.Arch}}",
+					Values:   map[string]string{"Name": "test", "OS": "linux", "Arch": "amd64"},
+				},
+			})
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			rel, err := u.CheckVersion(context.Background(), "")
+			if err != nil {
+				t.Fatalf("CheckVersion() error = %v", err)
+			}
+
+			if (rel.PatchURL != "") != tt.wantPatchURL {
+				t.Errorf("CheckVersion() patch URL = %q, want patch %v", rel.PatchURL, tt.wantPatchURL)
+			}
+
+			target := filepath.Join(t.TempDir(), "test")
+			if err := os.WriteFile(target, []byte(tt.installed), 0o755); err != nil {
+				t.Fatal(err)
+			}
+
+			if err := u.UpdateTo(context.Background(), rel, &update.Options{TargetPath: target}); err != nil {
+				t.Fatalf("UpdateTo() error = %v", err)
+			}
+
+			got, _ := os.ReadFile(target)
+			if string(got) != string(binary) {
+				t.Errorf("UpdateTo() target = %q, want %q", got, binary)
+			}
+
+			if downloads != tt.wantDownloads {
+				t.Errorf("full downloads = %d, want %d", downloads, tt.wantDownloads)
+			}
+		})
+	}
+}
@@ -0,0 +1,346 @@
+package selfupdate
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/aatumaykin/go-self-update/selfupdate/release"
+)
+
+type (
+	// Progress describes the state of an asset download. Total is 0 when
+	// the size is unknown, in which case ETA is 0 too.
+	Progress struct {
+		Downloaded int64
+		Total      int64
+		// Rate is the average speed of the current download in bytes per
+		// second. Bytes resumed from a previous attempt are not counted.
+		Rate float64
+		ETA  time.Duration
+	}
+
+	ProgressFunc func(p Progress)
+
+	progressWriter struct {
+		w          io.Writer
+		fn         ProgressFunc
+		start      time.Time
+		last       time.Time
+		resumed    int64
+		downloaded int64
+		total      int64
+	}
+
+	// stagingFile is the file an asset is downloaded to, with the validator
+	// of the content it holds.
+	stagingFile struct {
+		f         *os.File
+		validator string
+	}
+
+	// interruptedError marks a download that failed while reading the body
+	// and can be resumed from the bytes already staged.
+	interruptedError struct {
+		err error
+	}
+)
+
+const (
+	maxResumeAttempts = 3
+	progressInterval  = 200 * time.Millisecond
+)
+
+func (e *interruptedError) Error() string {
+	return fmt.Sprintf("download interrupted: %v", e.err)
+}
+
+func (e *interruptedError) Unwrap() error {
+	return e.err
+}
+
+func (w *progressWriter) Write(p []byte) (int, error) {
+	n, err := w.w.Write(p)
+	w.downloaded += int64(n)
+
+	if now := time.Now(); now.Sub(w.last) >= progressInterval {
+		w.last = now
+		w.report()
+	}
+
+	return n, err
+}
+
+func (w *progressWriter) report() {
+	if w.fn == nil {
+		return
+	}
+
+	p := Progress{
+		Downloaded: w.downloaded,
+		Total:      w.total,
+	}
+
+	if elapsed := time.Since(w.start).Seconds(); elapsed > 0 {
+		p.Rate = float64(w.downloaded-w.resumed) / elapsed
+	}
+
+	if p.Rate > 0 && p.Total > p.Downloaded {
+		p.ETA = time.Duration(float64(p.Total-p.Downloaded) / p.Rate * float64(time.Second))
+	}
+
+	w.fn(p)
+}
+
+// stagingPath returns the file the asset is downloaded to. It is derived
+// from the asset URL, so an interrupted download of the same asset is
+// resumed by the next UpdateTo call.
+func (u *Updater) stagingPath(rel *Release) string {
+	sum := sha256.Sum256([]byte(rel.AssetURL))
+	name := hex.EncodeToString(sum[:8]) + "-" + filepath.Base(rel.AssetName) + ".part"
+
+	return filepath.Join(u.stagingDir, name)
+}
+
+// validatorPath returns the file holding the ETag or Last-Modified date of
+// the staged asset, which is sent as If-Range so that a resumed download is
+// never spliced onto bytes of different content behind the same URL.
+func validatorPath(staged string) string {
+	return staged + ".validator"
+}
+
+// removeStaged removes a staging file and its validator.
+func removeStaged(path string) {
+	_ = os.Remove(path)
+	_ = os.Remove(validatorPath(path))
+}
+
+// responseValidator returns the strong ETag of a response, or else its
+// Last-Modified date. Weak ETags are not allowed in If-Range.
+func responseValidator(h http.Header) string {
+	if etag := h.Get("ETag"); etag != "" && !strings.HasPrefix(etag, "W/") {
+		return etag
+	}
+
+	return h.Get("Last-Modified")
+}
+
+// download stores the asset in the staging file, resuming it with HTTP
+// Range requests, and returns the path of the complete file.
+func (u *Updater) download(ctx context.Context, rel *Release) (string, error) {
+	if err := os.MkdirAll(u.stagingDir, 0o700); err != nil {
+		return "", fmt.Errorf("failed to create staging directory: %w", err)
+	}
+
+	path := u.stagingPath(rel)
+
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY, 0o600)
+	if err != nil {
+		return "", fmt.Errorf("failed to open staging file: %w", err)
+	}
+	defer f.Close()
+
+	info, err := f.Stat()
+	if err != nil {
+		return "", fmt.Errorf("failed to open staging file: %w", err)
+	}
+
+	// Bytes staged by an earlier call can only be resumed if it recorded
+	// what they were part of.
+	offset := info.Size()
+	validator, _ := os.ReadFile(validatorPath(path))
+	if offset > 0 && len(validator) == 0 {
+		u.logger.InfoContext(ctx, "Discarding staged download without validator", "path", path)
+		offset = 0
+	}
+
+	pw := &progressWriter{
+		w:          f,
+		fn:         u.progress,
+		start:      time.Now(),
+		resumed:    offset,
+		downloaded: offset,
+		total:      int64(rel.AssetByteSize),
+	}
+
+	stage := &stagingFile{f: f, validator: string(validator)}
+
+	for attempt := 0; ; attempt++ {
+		err = u.downloadRange(ctx, rel.AssetURL, stage, pw)
+
+		var interrupted *interruptedError
+		if err == nil || !errors.As(err, &interrupted) || attempt >= maxResumeAttempts || ctx.Err() != nil {
+			break
+		}
+
+		u.logger.WarnContext(ctx, "Download interrupted", "url", rel.AssetURL, "offset", pw.downloaded, "error", interrupted.err)
+	}
+
+	if err != nil {
+		return "", err
+	}
+
+	pw.report()
+
+	return path, nil
+}
+
+func (u *Updater) downloadRange(ctx context.Context, url string, stage *stagingFile, pw *progressWriter) error {
+	req, err := u.newRequest(ctx, url)
+	if err != nil {
+		return err
+	}
+
+	f := stage.f
+
+	offset := pw.downloaded
+	if offset > 0 {
+		req.Header.Set("Range", fmt.Sprintf("bytes=%d-", offset))
+		if stage.validator != "" {
+			req.Header.Set("If-Range", stage.validator)
+		}
+	}
+
+	resp, err := u.httpClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to send HTTP request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	switch resp.StatusCode {
+	case http.StatusOK:
+		offset = 0
+		pw.resumed = 0
+		if resp.ContentLength > 0 {
+			pw.total = resp.ContentLength
+		}
+		if err := stage.setValidator(responseValidator(resp.Header)); err != nil {
+			return err
+		}
+	case http.StatusPartialContent:
+		start, total, ok := parseContentRange(resp.Header.Get("Content-Range"))
+		if !ok || start != offset {
+			return fmt.Errorf("failed to GET %v: unexpected Content-Range %q", url, resp.Header.Get("Content-Range"))
+		}
+		// Servers that ignore If-Range still reveal a changed asset.
+		if v := responseValidator(resp.Header); v != "" && stage.validator != "" && v != stage.validator {
+			pw.resumed = 0
+			pw.downloaded = 0
+			if err := f.Truncate(0); err != nil {
+				return fmt.Errorf("failed to truncate staging file: %w", err)
+			}
+			return &interruptedError{err: fmt.Errorf("staging file does not match %v", url)}
+		}
+		if total > 0 {
+			pw.total = total
+		}
+		u.logger.InfoContext(ctx, "Resuming download", "offset", offset)
+	case http.StatusRequestedRangeNotSatisfiable:
+		// The staging file already holds the whole asset, unless it is
+		// larger than the asset, in which case it is downloaded again.
+		_, total, ok := parseContentRange(resp.Header.Get("Content-Range"))
+		if ok && total == offset {
+			return nil
+		}
+		pw.resumed = 0
+		pw.downloaded = 0
+		if err := f.Truncate(0); err != nil {
+			return fmt.Errorf("failed to truncate staging file: %w", err)
+		}
+		return &interruptedError{err: fmt.Errorf("staging file does not match %v", url)}
+	default:
+		if err := release.CheckResponse(resp); err != nil {
+			return err
+		}
+
+		return fmt.Errorf("failed to GET %v: unexpected status %s", url, resp.Status)
+	}
+
+	if err := f.Truncate(offset); err != nil {
+		return fmt.Errorf("failed to truncate staging file: %w", err)
+	}
+
+	if _, err := f.Seek(offset, io.SeekStart); err != nil {
+		return fmt.Errorf("failed to seek staging file: %w", err)
+	}
+
+	pw.downloaded = offset
+
+	if _, err := io.Copy(pw, resp.Body); err != nil {
+		return &interruptedError{err: err}
+	}
+
+	if pw.total > 0 && pw.downloaded < pw.total {
+		return &interruptedError{err: io.ErrUnexpectedEOF}
+	}
+
+	return nil
+}
+
+// setValidator records the validator of a download that starts from
+// scratch. Without one, the staged bytes are not resumed by later calls.
+func (s *stagingFile) setValidator(v string) error {
+	s.validator = v
+
+	path := validatorPath(s.f.Name())
+	if v == "" {
+		if err := os.Remove(path); err != nil && !errors.Is(err, os.ErrNotExist) {
+			return fmt.Errorf("failed to remove staging validator: %w", err)
+		}
+
+		return nil
+	}
+
+	if err := os.WriteFile(path, []byte(v), 0o600); err != nil {
+		return fmt.Errorf("failed to write staging validator: %w", err)
+	}
+
+	return nil
+}
+
+// parseContentRange parses "bytes <start>-<end>/<total>" and
+// "bytes */<total>". Total is -1 when it is unknown ("*").
+func parseContentRange(value string) (start, total int64, ok bool) {
+	rest, ok := strings.CutPrefix(value, "bytes ")
+	if !ok {
+		return 0, 0, false
+	}
+
+	rng, size, ok := strings.Cut(rest, "/")
+	if !ok {
+		return 0, 0, false
+	}
+
+	total = -1
+	if size != "*" {
+		var err error
+		if total, err = strconv.ParseInt(size, 10, 64); err != nil {
+			return 0, 0, false
+		}
+	}
+
+	if rng == "*" {
+		return 0, total, true
+	}
+
+	first, _, ok := strings.Cut(rng, "-")
+	if !ok {
+		return 0, 0, false
+	}
+
+	start, err := strconv.ParseInt(first, 10, 64)
+	if err != nil {
+		return 0, 0, false
+	}
+
+	return start, total, true
+}
@@ -0,0 +1,166 @@
+package selfupdate
+
+import (
+	"bytes"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strconv"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/inconshreveable/go-update"
+)
+
+func TestUpdater_UpdateTo_ResumeDownload(t *testing.T) {
+	binary := bytes.Repeat([]byte("0123456789"), 1000)
+
+	var requests, ranged atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if requests.Add(1) == 1 {
+			// Drop the connection halfway through the first response.
+			w.Header().Set("Content-Length", strconv.Itoa(len(binary)))
+			_, _ = w.Write(binary[:len(binary)/2])
+			return
+		}
+
+		if r.Header.Get("Range") != "" {
+			ranged.Add(1)
+		}
+		http.ServeContent(w, r, "test", time.Time{}, bytes.NewReader(binary))
+	}))
+	defer srv.Close()
+
+	target := filepath.Join(t.TempDir(), "test")
+	if err := os.WriteFile(target, []byte("old binary"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+
+	var last Progress
+	u, _ := New(Config{
+		StagingDir: t.TempDir(),
+		Progress: func(p Progress) {
+			last = p
+		},
+	})
+
+	rel := &Release{AssetName: "test", AssetURL: srv.URL + "/test", AssetByteSize: len(binary)}
+	if err := u.UpdateTo(context.Background(), rel, &update.Options{TargetPath: target}); err != nil {
+		t.Fatalf("UpdateTo() error = %v", err)
+	}
+
+	got, _ := os.ReadFile(target)
+	if !bytes.Equal(got, binary) {
+		t.Errorf("UpdateTo() target has %d bytes, want %d", len(got), len(binary))
+	}
+
+	if requests.Load() != 2 || ranged.Load() != 1 {
+		t.Errorf("got %d requests (%d with Range), want 2 (1 with Range)", requests.Load(), ranged.Load())
+	}
+
+	if last.Downloaded != int64(len(binary)) || last.Total != int64(len(binary)) {
+		t.Errorf("last progress = %+v, want %d of %d bytes", last, len(binary), len(binary))
+	}
+
+	if _, err := os.Stat(u.stagingPath(rel)); !os.IsNotExist(err) {
+		t.Errorf("staging file was not removed: %v", err)
+	}
+}
+
+func TestUpdater_UpdateTo_ResumeStagedDownload(t *testing.T) {
+	binary := bytes.Repeat([]byte("0123456789"), 1000)
+
+	tests := []struct {
+		name      string
+		staged    []byte
+		validator string
+		wantBytes int64
+	}{
+		{
+			name:      "matching validator should resume",
+			staged:    binary[:4000],
+			validator: `"v2"`,
+			wantBytes: int64(len(binary) - 4000),
+		},
+		{
+			name:      "changed asset should be downloaded again",
+			staged:    bytes.Repeat([]byte("x"), 4000),
+			validator: `"v1"`,
+			wantBytes: int64(len(binary)),
+		},
+		{
+			name:      "missing validator should download again",
+			staged:    bytes.Repeat([]byte("x"), 4000),
+			wantBytes: int64(len(binary)),
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var served atomic.Int64
+			srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				rec := httptest.NewRecorder()
+				rec.Header().Set("ETag", `"v2"`)
+				http.ServeContent(rec, r, "test", time.Time{}, bytes.NewReader(binary))
+				served.Add(int64(rec.Body.Len()))
+
+				for k, v := range rec.Header() {
+					w.Header()[k] = v
+				}
+				w.WriteHeader(rec.Code)
+				_, _ = w.Write(rec.Body.Bytes())
+			}))
+			defer srv.Close()
+
+			target := filepath.Join(t.TempDir(), "test")
+			if err := os.WriteFile(target, []byte("old binary"), 0o755); err != nil {
+				t.Fatal(err)
+			}
+
+			var rates []float64
+			u, _ := New(Config{
+				StagingDir: t.TempDir(),
+				Progress: func(p Progress) {
+					rates = append(rates, p.Rate)
+				},
+			})
+			rel := &Release{AssetName: "test", AssetURL: srv.URL + "/test", AssetByteSize: len(binary)}
+
+			// A previous UpdateTo call left the first 4000 bytes behind.
+			staged := u.stagingPath(rel)
+			if err := os.WriteFile(staged, tt.staged, 0o600); err != nil {
+				t.Fatal(err)
+			}
+			if tt.validator != "" {
+				if err := os.WriteFile(validatorPath(staged), []byte(tt.validator), 0o600); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			if err := u.UpdateTo(context.Background(), rel, &update.Options{TargetPath: target}); err != nil {
+				t.Fatalf("UpdateTo() error = %v", err)
+			}
+
+			got, _ := os.ReadFile(target)
+			if !bytes.Equal(got, binary) {
+				t.Errorf("UpdateTo() target has %d bytes, want %d", len(got), len(binary))
+			}
+
+			if served.Load() != tt.wantBytes {
+				t.Errorf("served %d bytes, want %d", served.Load(), tt.wantBytes)
+			}
+
+			for _, rate := range rates {
+				if rate < 0 {
+					t.Errorf("progress rate = %v, want >= 0", rate)
+				}
+			}
+
+			if _, err := os.Stat(validatorPath(staged)); !os.IsNotExist(err) {
+				t.Errorf("staging validator was not removed: %v", err)
+			}
+		})
+	}
+}
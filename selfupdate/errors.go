@@ -0,0 +1,67 @@
+package selfupdate
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/aatumaykin/go-self-update/selfupdate/release"
+)
+
+type (
+	// HTTPStatusError is returned for unexpected HTTP response statuses of
+	// API requests and downloads.
+	HTTPStatusError = release.HTTPStatusError
+	// RateLimitError matches ErrRateLimited and carries the reset time.
+	RateLimitError = release.RateLimitError
+	// RateLimit is the API quota reported with the last response.
+	RateLimit = release.RateLimit
+
+	// AssetNotFoundError matches ErrAssetNotFound and lists the assets of
+	// the release that did not match.
+	AssetNotFoundError struct {
+		Name       string
+		Release    string
+		Candidates []string
+	}
+)
+
+var (
+	ErrNoUpdateAvailable     = errors.New("no update available")
+	ErrReleaseNotFound       = release.ErrReleaseNotFound
+	ErrRateLimited           = release.ErrRateLimited
+	ErrAssetNotFound         = errors.New("asset not found")
+	ErrUnsupportedRepository = errors.New("unsupported repository type")
+	ErrUnknownCurrentVersion = errors.New("current version is unknown")
+	ErrDowngrade             = errors.New("downgrade is not allowed")
+	ErrChecksumMismatch      = errors.New("checksum mismatch")
+	ErrChecksumMissing       = errors.New("checksum not found")
+	ErrUnsupportedArchive    = errors.New("unsupported archive format")
+	ErrExecutableNotFound    = errors.New("executable not found in archive")
+	ErrSignatureMissing      = errors.New("signature not found")
+	ErrSignatureInvalid      = errors.New("invalid signature")
+	ErrRestartUnsupported    = errors.New("restart is not supported")
+)
+
+func (e *AssetNotFoundError) Error() string {
+	return fmt.Sprintf("asset %s not found in release %s (available: %s)", e.Name, e.Release, strings.Join(e.Candidates, ", "))
+}
+
+func (e *AssetNotFoundError) Is(target error) bool {
+	return target == ErrAssetNotFound
+}
+
+func newAssetNotFoundError(r release.Release, name string) *AssetNotFoundError {
+	assets := r.GetAssets()
+
+	candidates := make([]string, 0, len(assets))
+	for _, a := range assets {
+		candidates = append(candidates, a.GetName())
+	}
+
+	return &AssetNotFoundError{
+		Name:       name,
+		Release:    r.GetTagName(),
+		Candidates: candidates,
+	}
+}
@@ -0,0 +1,142 @@
+package selfupdate
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"strconv"
+	"testing"
+	"time"
+)
+
+func TestUpdater_CheckVersion_Errors(t *testing.T) {
+	reset := time.Now().Add(time.Hour).Truncate(time.Second)
+
+	tests := []struct {
+		name           string
+		repositoryType RepositoryType
+		handler        http.HandlerFunc
+		check          func(t *testing.T, err error)
+	}{
+		{
+			name:           "github: missing release",
+			repositoryType: Github,
+			handler: func(w http.ResponseWriter, r *http.Request) {
+				http.NotFound(w, r)
+			},
+			check: func(t *testing.T, err error) {
+				var statusErr *HTTPStatusError
+				if !errors.Is(err, ErrReleaseNotFound) || !errors.As(err, &statusErr) || statusErr.StatusCode != http.StatusNotFound {
+					t.Errorf("error = %v, want ErrReleaseNotFound with 404 HTTPStatusError", err)
+				}
+			},
+		},
+		{
+			name:           "gitea: server error",
+			repositoryType: Gitea,
+			handler: func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(http.StatusBadGateway)
+			},
+			check: func(t *testing.T, err error) {
+				var statusErr *HTTPStatusError
+				if errors.Is(err, ErrReleaseNotFound) || !errors.As(err, &statusErr) || statusErr.StatusCode != http.StatusBadGateway {
+					t.Errorf("error = %v, want 502 HTTPStatusError", err)
+				}
+			},
+		},
+		{
+			name:           "github: rate limited",
+			repositoryType: Github,
+			handler: func(w http.ResponseWriter, r *http.Request) {
+				w.Header().Set("X-RateLimit-Limit", "60")
+				w.Header().Set("X-RateLimit-Remaining", "0")
+				w.Header().Set("X-RateLimit-Reset", strconv.FormatInt(reset.Unix(), 10))
+				w.WriteHeader(http.StatusForbidden)
+			},
+			check: func(t *testing.T, err error) {
+				var rlErr *RateLimitError
+				if !errors.Is(err, ErrRateLimited) || !errors.As(err, &rlErr) {
+					t.Fatalf("error = %v, want RateLimitError", err)
+				}
+				if !rlErr.Reset.Equal(reset) || rlErr.Limit != 60 || rlErr.Remaining != 0 {
+					t.Errorf("RateLimitError = %+v, want reset %v", rlErr, reset)
+				}
+			},
+		},
+		{
+			name:           "gitea: forbidden is not a rate limit",
+			repositoryType: Gitea,
+			handler: func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(http.StatusForbidden)
+			},
+			check: func(t *testing.T, err error) {
+				var statusErr *HTTPStatusError
+				if errors.Is(err, ErrRateLimited) || !errors.As(err, &statusErr) || statusErr.StatusCode != http.StatusForbidden {
+					t.Errorf("error = %v, want 403 HTTPStatusError", err)
+				}
+			},
+		},
+		{
+			name:           "gitlab: retry after",
+			repositoryType: GitLab,
+			handler: func(w http.ResponseWriter, r *http.Request) {
+				w.Header().Set("Retry-After", "30")
+				w.WriteHeader(http.StatusTooManyRequests)
+			},
+			check: func(t *testing.T, err error) {
+				var rlErr *RateLimitError
+				if !errors.As(err, &rlErr) || time.Until(rlErr.Reset) <= 0 {
+					t.Errorf("error = %v, want RateLimitError with reset in the future", err)
+				}
+			},
+		},
+		{
+			name:           "github: asset not found",
+			repositoryType: Github,
+			handler: func(w http.ResponseWriter, r *http.Request) {
+				_, _ = w.Write([]byte(`{"tag_name": "1.0.0", "assets": [{"name": "test-linux-arm64"}, {"name": "test-darwin-amd64"}]}`))
+			},
+			check: func(t *testing.T, err error) {
+				var assetErr *AssetNotFoundError
+				if !errors.Is(err, ErrAssetNotFound) || !errors.As(err, &assetErr) {
+					t.Fatalf("error = %v, want AssetNotFoundError", err)
+				}
+				if assetErr.Name != "test-linux-amd64" || !slices.Equal(assetErr.Candidates, []string{"test-linux-arm64", "test-darwin-amd64"}) {
+					t.Errorf("AssetNotFoundError = %+v", assetErr)
+				}
+			},
+		},
+		{
+			name:           "unsupported repository",
+			repositoryType: "Bitbucket",
+			check: func(t *testing.T, err error) {
+				if !errors.Is(err, ErrUnsupportedRepository) {
+					t.Errorf("error = %v, want ErrUnsupportedRepository", err)
+				}
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			srv := httptest.NewServer(tt.handler)
+			defer srv.Close()
+
+			u, _ := New(Config{
+				RepositoryType: tt.repositoryType,
+				APIBaseURL:     srv.URL,
+				Owner:          "owner",
+				Repo:           "repo",
+				Retry:          &Retry{InitialBackoff: time.Millisecond, MaxBackoff: time.Second},
+				Filter: &Filter{
+					Template: "{{.Name}}-{{.OS}}-{{.Arch}}",
+					Values:   map[string]string{"Name": "test", "OS": "linux", "Arch": "amd64"},
+				},
+			})
+
+			_, err := u.CheckVersion(context.Background(), "1.0.0")
+			tt.check(t, err)
+		})
+	}
+}
@@ -0,0 +1,103 @@
+package selfupdate
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/aatumaykin/go-self-update/selfupdate/local"
+)
+
+// fileTransport serves file:// URLs from the local filesystem, including
+// the Range requests used to resume downloads, so that releases copied to
+// an air-gapped machine go through the same download path.
+type fileTransport struct {
+	base http.RoundTripper
+}
+
+func (t *fileTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if req.URL.Scheme != "file" {
+		return t.base.RoundTrip(req)
+	}
+
+	path, err := local.FilePath(req.URL)
+	if err != nil {
+		return nil, err
+	}
+
+	f, err := os.Open(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return fileResponse(req, http.StatusNotFound, http.NoBody, 0), nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	info, err := f.Stat()
+	if err != nil || info.IsDir() {
+		_ = f.Close()
+		return fileResponse(req, http.StatusNotFound, http.NoBody, 0), nil
+	}
+
+	size := info.Size()
+
+	offset, ok := parseRangeStart(req.Header.Get("Range"))
+	if !ok {
+		return fileResponse(req, http.StatusOK, f, size), nil
+	}
+
+	if offset >= size {
+		_ = f.Close()
+		resp := fileResponse(req, http.StatusRequestedRangeNotSatisfiable, http.NoBody, 0)
+		resp.Header.Set("Content-Range", fmt.Sprintf("bytes */%d", size))
+		return resp, nil
+	}
+
+	if _, err := f.Seek(offset, io.SeekStart); err != nil {
+		_ = f.Close()
+		return nil, err
+	}
+
+	resp := fileResponse(req, http.StatusPartialContent, f, size-offset)
+	resp.Header.Set("Content-Range", fmt.Sprintf("bytes %d-%d/%d", offset, size-1, size))
+
+	return resp, nil
+}
+
+func fileResponse(req *http.Request, code int, body io.ReadCloser, length int64) *http.Response {
+	return &http.Response{
+		Status:        fmt.Sprintf("%d %s", code, http.StatusText(code)),
+		StatusCode:    code,
+		Proto:         "HTTP/1.1",
+		ProtoMajor:    1,
+		ProtoMinor:    1,
+		Header:        make(http.Header),
+		Body:          body,
+		ContentLength: length,
+		Request:       req,
+	}
+}
+
+// parseRangeStart parses the "bytes=<start>-" header sent by downloadRange.
+func parseRangeStart(header string) (int64, bool) {
+	spec, ok := strings.CutPrefix(header, "bytes=")
+	if !ok {
+		return 0, false
+	}
+
+	start, end, ok := strings.Cut(spec, "-")
+	if !ok || end != "" {
+		return 0, false
+	}
+
+	offset, err := strconv.ParseInt(start, 10, 64)
+	if err != nil || offset < 0 {
+		return 0, false
+	}
+
+	return offset, true
+}
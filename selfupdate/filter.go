@@ -0,0 +1,186 @@
+package selfupdate
+
+import (
+	"cmp"
+	"fmt"
+	"maps"
+	"path"
+	"regexp"
+	"runtime"
+	"strings"
+
+	"github.com/aatumaykin/go-self-update/selfupdate/release"
+)
+
+type (
+	// FilterRule selects release assets by exactly one of Template, Glob or
+	// Regexp. All three are rendered with the Filter values first, e.g.
+	// Glob "{{.Name}}_*_{{.OS}}_{{.Arch}}.tar.gz". Matching is
+	// case-insensitive and covers the whole asset name.
+	FilterRule struct {
+		Template string
+		Glob     string
+		// Regexp values are inserted literally, so that a version such as
+		// "1.2.0" only matches itself.
+		Regexp string
+	}
+
+	// assetMatch is an asset with the rule and template values that
+	// matched it.
+	assetMatch struct {
+		asset  release.Asset
+		rule   FilterRule
+		values map[string]string
+	}
+)
+
+func (r FilterRule) String() string {
+	switch {
+	case r.Glob != "":
+		return "glob " + r.Glob
+	case r.Regexp != "":
+		return "regexp " + r.Regexp
+	default:
+		return "template " + r.Template
+	}
+}
+
+// matcher renders the rule and returns a function matching asset names and
+// the rendered pattern.
+func (r FilterRule) matcher(values map[string]string) (func(name string) bool, string, error) {
+	switch {
+	case r.Glob != "":
+		pattern, err := renderTemplate(r.Glob, values)
+		if err != nil {
+			return nil, "", err
+		}
+
+		pattern = strings.ToLower(pattern)
+		if _, err := path.Match(pattern, ""); err != nil {
+			return nil, "", fmt.Errorf("invalid glob %q: %w", r.Glob, err)
+		}
+
+		return func(name string) bool {
+			ok, _ := path.Match(pattern, strings.ToLower(name))
+			return ok
+		}, pattern, nil
+	case r.Regexp != "":
+		quoted := make(map[string]string, len(values))
+		for k, v := range values {
+			quoted[k] = regexp.QuoteMeta(v)
+		}
+
+		pattern, err := renderTemplate(r.Regexp, quoted)
+		if err != nil {
+			return nil, "", err
+		}
+
+		re, err := regexp.Compile("(?i)^(?:" + pattern + ")$")
+		if err != nil {
+			return nil, "", fmt.Errorf("invalid regexp %q: %w", r.Regexp, err)
+		}
+
+		return re.MatchString, pattern, nil
+	default:
+		name, err := renderTemplate(r.Template, values)
+		if err != nil {
+			return nil, "", err
+		}
+
+		return func(s string) bool {
+			return strings.EqualFold(s, name)
+		}, name, nil
+	}
+}
+
+func (f *Filter) rules() []FilterRule {
+	rules := make([]FilterRule, 0, len(f.Rules)+1)
+	if f.Template != "" {
+		rules = append(rules, FilterRule{Template: f.Template})
+	}
+
+	return append(rules, f.Rules...)
+}
+
+// score ranks an asset name by Prefer; lower is better.
+func (f *Filter) score(name string) int {
+	name = strings.ToLower(name)
+	for i, p := range f.Prefer {
+		if strings.Contains(name, strings.ToLower(p)) {
+			return i
+		}
+	}
+
+	return len(f.Prefer)
+}
+
+// findAsset returns the asset of r matched by the first rule of the
+// filter. Each rule is tried with every alias of the running OS and
+// architecture before the next rule, and assets matched by the same rule
+// and platform names are ranked by Filter.Prefer.
+func (u *Updater) findAsset(r release.Release) (*assetMatch, error) {
+	values := u.templateValues(r.GetTagName(), r.GetVersion())
+
+	oses, arches := platformCandidates(u.filter.Values)
+	assets := r.GetAssets()
+
+	var tried []string
+
+	seen := make(map[string]bool)
+	for _, rule := range u.filter.rules() {
+		for _, goos := range oses {
+			for _, arch := range arches {
+				values["OS"], values["Arch"] = goos, arch
+
+				match, pattern, err := rule.matcher(values)
+				if err != nil {
+					return nil, err
+				}
+
+				key := strings.ToLower(rule.String() + "\x00" + pattern)
+				if seen[key] {
+					continue
+				}
+				seen[key] = true
+				tried = append(tried, pattern)
+
+				var best release.Asset
+
+				for _, asset := range assets {
+					if !match(asset.GetName()) {
+						continue
+					}
+
+					if best == nil || u.filter.score(asset.GetName()) < u.filter.score(best.GetName()) {
+						best = asset
+					}
+				}
+
+				if best != nil {
+					return &assetMatch{asset: best, rule: rule, values: maps.Clone(values)}, nil
+				}
+			}
+		}
+	}
+
+	// Providers may resolve names that are not listed with the release,
+	// e.g. GitLab generic packages.
+	if u.filter.Template != "" {
+		values["OS"], values["Arch"] = oses[0], cmp.Or(u.filter.Values["Arch"], runtime.GOARCH)
+
+		name, err := renderTemplate(u.filter.Template, values)
+		if err != nil {
+			return nil, err
+		}
+
+		if asset, found := r.FindAsset(name); found {
+			return &assetMatch{asset: asset, rule: FilterRule{Template: u.filter.Template}, values: values}, nil
+		}
+	}
+
+	if len(tried) == 0 {
+		return nil, newAssetNotFoundError(r, "")
+	}
+
+	return nil, newAssetNotFoundError(r, tried[0])
+}
@@ -0,0 +1,113 @@
+package selfupdate
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/aatumaykin/go-self-update/selfupdate/manifest"
+)
+
+func TestUpdater_CheckVersion_FilterRules(t *testing.T) {
+	tests := []struct {
+		name     string
+		filter   Filter
+		assets   []string
+		want     string
+		wantRule FilterRule
+		wantErr  bool
+	}{
+		{
+			name:     "template",
+			filter:   Filter{Template: "tool-{{.Version}}-{{.OS}}-{{.Arch}}"},
+			assets:   []string{"tool-v1.2.0-linux-amd64"},
+			want:     "tool-v1.2.0-linux-amd64",
+			wantRule: FilterRule{Template: "tool-{{.Version}}-{{.OS}}-{{.Arch}}"},
+		},
+		{
+			name: "first matching rule wins",
+			filter: Filter{Rules: []FilterRule{
+				{Template: "tool-{{.OS}}-{{.Arch}}"},
+				{Glob: "tool_*_{{.OS}}_{{.Arch}}.*"},
+			}},
+			assets:   []string{"tool_1.2.0_Linux_amd64.zip"},
+			want:     "tool_1.2.0_Linux_amd64.zip",
+			wantRule: FilterRule{Glob: "tool_*_{{.OS}}_{{.Arch}}.*"},
+		},
+		{
+			name: "regexp with optional v prefix",
+			filter: Filter{Rules: []FilterRule{
+				{Regexp: `tool-v?1\.2\.0-{{.OS}}-{{.Arch}}(-static)?`},
+			}},
+			assets:   []string{"tool-1.2.0-linux-amd64.sha256", "tool-1.2.0-linux-amd64"},
+			want:     "tool-1.2.0-linux-amd64",
+			wantRule: FilterRule{Regexp: `tool-v?1\.2\.0-{{.OS}}-{{.Arch}}(-static)?`},
+		},
+		{
+			name: "preference",
+			filter: Filter{
+				Rules:  []FilterRule{{Glob: "tool*{{.OS}}*{{.Arch}}*"}},
+				Prefer: []string{"static", ".tar.gz"},
+			},
+			assets:   []string{"tool-linux-amd64.zip", "tool-linux-amd64.tar.gz", "tool-linux-amd64-static.zip"},
+			want:     "tool-linux-amd64-static.zip",
+			wantRule: FilterRule{Glob: "tool*{{.OS}}*{{.Arch}}*"},
+		},
+		{
+			name:    "invalid regexp",
+			filter:  Filter{Rules: []FilterRule{{Regexp: "tool-("}}},
+			assets:  []string{"tool-linux-amd64"},
+			wantErr: true,
+		},
+		{
+			name:    "no match",
+			filter:  Filter{Rules: []FilterRule{{Glob: "*.deb"}}},
+			assets:  []string{"tool-linux-amd64"},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := manifest.Release{Version: "1.2.0", Tag: "v1.2.0"}
+			for _, name := range tt.assets {
+				r.Assets = append(r.Assets, manifest.Asset{Name: name})
+			}
+
+			filter := tt.filter
+			filter.Values = map[string]string{"OS": "linux", "Arch": "amd64"}
+
+			u, err := New(Config{
+				Source: &staticProvider{releases: []manifest.Release{r}},
+				Filter: &filter,
+			})
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			rel, err := u.CheckVersion(context.Background(), "")
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("CheckVersion() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err != nil {
+				return
+			}
+
+			if rel.AssetName != tt.want {
+				t.Errorf("CheckVersion() asset = %s, want %s", rel.AssetName, tt.want)
+			}
+
+			if rel.MatchedRule != tt.wantRule {
+				t.Errorf("CheckVersion() rule = %v, want %v", rel.MatchedRule, tt.wantRule)
+			}
+
+			// Later templates, e.g. Target.Asset, see the values the
+			// filter matched with.
+			if tpl := rel.MatchedRule.Template; tpl != "" {
+				name, err := renderTemplate(tpl, u.assetValues(rel))
+				if err != nil || !strings.EqualFold(name, rel.AssetName) {
+					t.Errorf("rendering %q with asset values = %q, %v, want %s", tpl, name, err, rel.AssetName)
+				}
+			}
+		})
+	}
+}
@@ -4,6 +4,7 @@ import (
 	"cmp"
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"net/http"
@@ -14,79 +15,146 @@ import (
 
 type (
 	Config struct {
-		APIBaseURL string
-		Filter     string
-		Owner      string
-		Repo       string
-		HTTPClient *http.Client
+		APIBaseURL  string
+		Filter      string
+		Owner       string
+		Repo        string
+		HTTPClient  *http.Client
+		TokenSource release.TokenSource
 	}
 	Client struct {
-		client     *http.Client
-		apiBaseURL string
-		filter     string
-		owner      string
-		repo       string
+		client      *http.Client
+		apiBaseURL  string
+		filter      string
+		owner       string
+		repo        string
+		tokenSource release.TokenSource
 	}
 )
 
-const latest = "latest"
+const (
+	latest  = "latest"
+	perPage = 50
+)
 
 func New(config Config) *Client {
 	return &Client{
-		client:     http.DefaultClient,
-		apiBaseURL: cmp.Or(config.APIBaseURL, "https://gitea.com/api/v1"),
-		filter:     config.Filter,
-		owner:      config.Owner,
-		repo:       config.Repo,
+		client:      cmp.Or(config.HTTPClient, http.DefaultClient),
+		apiBaseURL:  cmp.Or(config.APIBaseURL, "https://gitea.com/api/v1"),
+		filter:      config.Filter,
+		owner:       config.Owner,
+		repo:        config.Repo,
+		tokenSource: config.TokenSource,
 	}
 }
 
+// Authorize adds the access token to requests sent to the API host.
+func (c *Client) Authorize(ctx context.Context, req *http.Request) error {
+	return release.Authorize(ctx, req, c.tokenSource, c.apiBaseURL, "token")
+}
+
 func (c *Client) GetVersionUrl(version string) string {
 	if version == latest {
 		return fmt.Sprintf("/repos/%s/%s/releases/latest", c.owner, c.repo)
 	}
 
-	return fmt.Sprintf("/repos/%s/%s/releases/tags/%s", c.owner, c.repo, version)
+	return c.tagPath(version)
 }
 
+func (c *Client) tagPath(tag string) string {
+	return fmt.Sprintf("/repos/%s/%s/releases/tags/%s", c.owner, c.repo, tag)
+}
+
+// GetRelease returns the latest release for "latest" and the release with
+// the given tag otherwise.
 func (c *Client) GetRelease(ctx context.Context, version string) (release.Release, error) {
-	url := strings.TrimSuffix(c.apiBaseURL, "/") + c.GetVersionUrl(version)
+	if version == latest {
+		return c.LatestRelease(ctx)
+	}
+
+	return c.ReleaseByTag(ctx, version)
+}
+
+func (c *Client) LatestRelease(ctx context.Context) (release.Release, error) {
+	return c.getRelease(ctx, c.GetVersionUrl(latest), latest)
+}
+
+func (c *Client) ReleaseByTag(ctx context.Context, tag string) (release.Release, error) {
+	return c.getRelease(ctx, c.tagPath(tag), tag)
+}
+
+func (c *Client) getRelease(ctx context.Context, path, version string) (release.Release, error) {
+	url := strings.TrimSuffix(c.apiBaseURL, "/") + path
 
 	var r Release
 
-	err := request(ctx, c.client, url, &r)
+	_, err := c.request(ctx, url, &r)
 	if err != nil {
+		var statusErr *release.HTTPStatusError
+		if errors.As(err, &statusErr) && statusErr.StatusCode == http.StatusNotFound {
+			return nil, fmt.Errorf("%w: %s: %w", release.ErrReleaseNotFound, version, err)
+		}
+
 		return nil, err
 	}
 
 	return &r, nil
 }
 
-func request(ctx context.Context, client *http.Client, url string, v interface{}) error {
+// ListReleases returns all releases of the repository, following the
+// pagination links of the API.
+func (c *Client) ListReleases(ctx context.Context) ([]release.Release, error) {
+	url := fmt.Sprintf("%s/repos/%s/%s/releases?limit=%d", strings.TrimSuffix(c.apiBaseURL, "/"), c.owner, c.repo, perPage)
+
+	var releases []release.Release
+
+	for url != "" {
+		var page []Release
+
+		header, err := c.request(ctx, url, &page)
+		if err != nil {
+			return nil, err
+		}
+
+		for i := range page {
+			releases = append(releases, &page[i])
+		}
+
+		url = release.NextPageURL(header)
+	}
+
+	return releases, nil
+}
+
+func (c *Client) request(ctx context.Context, url string, v interface{}) (http.Header, error) {
 	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
 	if err != nil {
-		return fmt.Errorf("failed to create HTTP request: %w", err)
+		return nil, fmt.Errorf("failed to create HTTP request: %w", err)
 	}
 	req.Header.Add("Accept", "application/json")
 
-	resp, err := client.Do(req)
+	if err := c.Authorize(ctx, req); err != nil {
+		return nil, err
+	}
+
+	resp, err := c.client.Do(req)
 	if err != nil {
-		return fmt.Errorf("failed to send HTTP request: %w", err)
+		return nil, fmt.Errorf("failed to send HTTP request: %w", err)
 	}
 	defer resp.Body.Close()
 
-	if resp.StatusCode != http.StatusOK {
-		return fmt.Errorf("failed to get release: %s", resp.Status)
+	if err := release.CheckResponse(resp); err != nil {
+		return nil, err
 	}
 
 	body, err := io.ReadAll(resp.Body)
 	if err != nil {
-		return fmt.Errorf("failed to GET %v: %w", url, err)
+		return nil, fmt.Errorf("failed to GET %v: %w", url, err)
 	}
 
 	if err := json.Unmarshal(body, &v); err != nil {
-		return fmt.Errorf("failed to GET %v: %w", url, err)
+		return nil, fmt.Errorf("failed to GET %v: %w", url, err)
 	}
 
-	return nil
+	return resp.Header, nil
 }
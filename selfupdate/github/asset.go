@@ -3,7 +3,12 @@ package github
 type Asset struct {
 	Name        string `json:"name"`
 	Size        int    `json:"size"`
+	URL         string `json:"url"`
 	DownloadURL string `json:"browser_download_url"`
+
+	// viaAPI makes GetDownloadURL return the API asset endpoint, which is
+	// the only way to download assets of private repositories.
+	viaAPI bool
 }
 
 func (a *Asset) GetName() string {
@@ -15,5 +20,9 @@ func (a *Asset) GetSize() int {
 }
 
 func (a *Asset) GetDownloadURL() string {
+	if a.viaAPI && a.URL != "" {
+		return a.URL
+	}
+
 	return a.DownloadURL
 }
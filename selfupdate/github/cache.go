@@ -0,0 +1,76 @@
+package github
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"os"
+	"path/filepath"
+)
+
+// cacheEntry is a response stored for conditional requests. GitHub does not
+// count a 304 Not Modified response against the rate limit.
+type cacheEntry struct {
+	ETag string          `json:"etag"`
+	Link string          `json:"link,omitempty"`
+	Body json.RawMessage `json:"body"`
+}
+
+func (c *Client) cachePath(url string) string {
+	sum := sha256.Sum256([]byte(url))
+
+	return filepath.Join(c.cacheDir, hex.EncodeToString(sum[:])+".json")
+}
+
+func (c *Client) loadCache(url string) *cacheEntry {
+	if c.cacheDir == "" {
+		return nil
+	}
+
+	data, err := os.ReadFile(c.cachePath(url))
+	if err != nil {
+		return nil
+	}
+
+	var entry cacheEntry
+	if err := json.Unmarshal(data, &entry); err != nil || entry.ETag == "" {
+		return nil
+	}
+
+	return &entry
+}
+
+// storeCache writes the entry atomically; failures only cost a full request
+// next time, so they are ignored.
+func (c *Client) storeCache(url string, entry cacheEntry) {
+	if c.cacheDir == "" || entry.ETag == "" {
+		return
+	}
+
+	data, err := json.Marshal(entry)
+	if err != nil {
+		return
+	}
+
+	if err := os.MkdirAll(c.cacheDir, 0o700); err != nil {
+		return
+	}
+
+	tmp, err := os.CreateTemp(c.cacheDir, "*.tmp")
+	if err != nil {
+		return
+	}
+
+	_, err = tmp.Write(data)
+	if cerr := tmp.Close(); err == nil {
+		err = cerr
+	}
+
+	if err == nil {
+		err = os.Rename(tmp.Name(), c.cachePath(url))
+	}
+
+	if err != nil {
+		_ = os.Remove(tmp.Name())
+	}
+}
@@ -4,89 +4,208 @@ import (
 	"cmp"
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"net/http"
 	"strings"
+	"sync"
 
 	"github.com/aatumaykin/go-self-update/selfupdate/release"
 )
 
 type (
 	Config struct {
-		APIBaseURL string
-		Filter     string
-		Owner      string
-		Repo       string
-		HTTPClient *http.Client
+		APIBaseURL  string
+		Filter      string
+		Owner       string
+		Repo        string
+		HTTPClient  *http.Client
+		TokenSource release.TokenSource
+		// CacheDir enables conditional requests: responses are stored with
+		// their ETag and revalidated with If-None-Match.
+		CacheDir string
 	}
 	Client struct {
-		client     *http.Client
-		apiBaseURL string
-		filter     string
-		owner      string
-		repo       string
+		client      *http.Client
+		apiBaseURL  string
+		filter      string
+		owner       string
+		repo        string
+		tokenSource release.TokenSource
+		cacheDir    string
+
+		mu        sync.Mutex
+		rateLimit *release.RateLimit
 	}
 )
 
-const latest = "latest"
+const (
+	latest  = "latest"
+	perPage = 100
+)
 
 func New(config Config) *Client {
 	return &Client{
-		client:     http.DefaultClient,
-		apiBaseURL: cmp.Or(config.APIBaseURL, "https://api.github.com"),
-		filter:     config.Filter,
-		owner:      config.Owner,
-		repo:       config.Repo,
+		client:      cmp.Or(config.HTTPClient, http.DefaultClient),
+		apiBaseURL:  cmp.Or(config.APIBaseURL, "https://api.github.com"),
+		filter:      config.Filter,
+		owner:       config.Owner,
+		repo:        config.Repo,
+		tokenSource: config.TokenSource,
+		cacheDir:    config.CacheDir,
 	}
 }
 
+// RateLimit returns the quota reported by the last API response.
+func (c *Client) RateLimit() (release.RateLimit, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.rateLimit == nil {
+		return release.RateLimit{}, false
+	}
+
+	return *c.rateLimit, true
+}
+
+// Authorize adds the access token as a Bearer token to requests sent to
+// the API host.
+func (c *Client) Authorize(ctx context.Context, req *http.Request) error {
+	return release.Authorize(ctx, req, c.tokenSource, c.apiBaseURL, "Bearer")
+}
+
 func (c *Client) GetVersionUrl(version string) string {
 	if version == latest {
 		return fmt.Sprintf("/repos/%s/%s/releases/latest", c.owner, c.repo)
 	}
 
-	return fmt.Sprintf("/repos/%s/%s/releases/tags/%s", c.owner, c.repo, version)
+	return c.tagPath(version)
+}
+
+func (c *Client) tagPath(tag string) string {
+	return fmt.Sprintf("/repos/%s/%s/releases/tags/%s", c.owner, c.repo, tag)
 }
 
+// GetRelease returns the latest release for "latest" and the release with
+// the given tag otherwise.
 func (c *Client) GetRelease(ctx context.Context, version string) (release.Release, error) {
-	url := strings.TrimSuffix(c.apiBaseURL, "/") + c.GetVersionUrl(version)
+	if version == latest {
+		return c.LatestRelease(ctx)
+	}
+
+	return c.ReleaseByTag(ctx, version)
+}
+
+func (c *Client) LatestRelease(ctx context.Context) (release.Release, error) {
+	return c.getRelease(ctx, c.GetVersionUrl(latest), latest)
+}
+
+func (c *Client) ReleaseByTag(ctx context.Context, tag string) (release.Release, error) {
+	return c.getRelease(ctx, c.tagPath(tag), tag)
+}
+
+func (c *Client) getRelease(ctx context.Context, path, version string) (release.Release, error) {
+	url := strings.TrimSuffix(c.apiBaseURL, "/") + path
 
 	var r Release
 
-	err := request(ctx, c.client, url, &r)
+	_, err := c.request(ctx, url, &r)
 	if err != nil {
+		var statusErr *release.HTTPStatusError
+		if errors.As(err, &statusErr) && statusErr.StatusCode == http.StatusNotFound {
+			return nil, fmt.Errorf("%w: %s: %w", release.ErrReleaseNotFound, version, err)
+		}
+
 		return nil, err
 	}
 
+	r.assetsViaAPI = c.tokenSource != nil
+
 	return &r, nil
 }
 
-func request(ctx context.Context, client *http.Client, url string, v interface{}) error {
+// ListReleases returns all releases of the repository, following the
+// pagination links of the API.
+func (c *Client) ListReleases(ctx context.Context) ([]release.Release, error) {
+	url := fmt.Sprintf("%s/repos/%s/%s/releases?per_page=%d", strings.TrimSuffix(c.apiBaseURL, "/"), c.owner, c.repo, perPage)
+
+	var releases []release.Release
+
+	for url != "" {
+		var page []Release
+
+		header, err := c.request(ctx, url, &page)
+		if err != nil {
+			return nil, err
+		}
+
+		for i := range page {
+			page[i].assetsViaAPI = c.tokenSource != nil
+			releases = append(releases, &page[i])
+		}
+
+		url = release.NextPageURL(header)
+	}
+
+	return releases, nil
+}
+
+func (c *Client) request(ctx context.Context, url string, v interface{}) (http.Header, error) {
 	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
 	if err != nil {
-		return fmt.Errorf("failed to create HTTP request: %w", err)
+		return nil, fmt.Errorf("failed to create HTTP request: %w", err)
 	}
 	req.Header.Add("Accept", "application/json")
 
-	resp, err := client.Do(req)
+	if err := c.Authorize(ctx, req); err != nil {
+		return nil, err
+	}
+
+	cached := c.loadCache(url)
+	if cached != nil {
+		req.Header.Set("If-None-Match", cached.ETag)
+	}
+
+	resp, err := c.client.Do(req)
 	if err != nil {
-		return fmt.Errorf("failed to send HTTP request: %w", err)
+		return nil, fmt.Errorf("failed to send HTTP request: %w", err)
 	}
 	defer resp.Body.Close()
 
-	if resp.StatusCode != http.StatusOK {
-		return fmt.Errorf("failed to get release: %s", resp.Status)
+	if rl, ok := release.ParseRateLimit(resp.Header); ok {
+		c.mu.Lock()
+		c.rateLimit = &rl
+		c.mu.Unlock()
 	}
 
-	body, err := io.ReadAll(resp.Body)
-	if err != nil {
-		return fmt.Errorf("failed to GET %v: %w", url, err)
+	var body []byte
+
+	if resp.StatusCode == http.StatusNotModified && cached != nil {
+		body = cached.Body
+		if resp.Header.Get("Link") == "" && cached.Link != "" {
+			resp.Header.Set("Link", cached.Link)
+		}
+	} else {
+		if err := release.CheckResponse(resp); err != nil {
+			return nil, err
+		}
+
+		body, err = io.ReadAll(resp.Body)
+		if err != nil {
+			return nil, fmt.Errorf("failed to GET %v: %w", url, err)
+		}
+
+		c.storeCache(url, cacheEntry{
+			ETag: resp.Header.Get("ETag"),
+			Link: resp.Header.Get("Link"),
+			Body: body,
+		})
 	}
 
 	if err := json.Unmarshal(body, &v); err != nil {
-		return fmt.Errorf("failed to GET %v: %w", url, err)
+		return nil, fmt.Errorf("failed to GET %v: %w", url, err)
 	}
 
-	return nil
+	return resp.Header, nil
 }
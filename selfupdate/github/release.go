@@ -9,12 +9,16 @@ import (
 )
 
 type Release struct {
-	TagName   string    `json:"tag_name"`
-	Name      string    `json:"name"`
-	Body      string    `json:"body"`
-	URL       string    `json:"html_url"`
-	Published time.Time `json:"published_at"`
-	Assets    []Asset   `json:"assets"`
+	TagName    string    `json:"tag_name"`
+	Name       string    `json:"name"`
+	Body       string    `json:"body"`
+	URL        string    `json:"html_url"`
+	Published  time.Time `json:"published_at"`
+	Draft      bool      `json:"draft"`
+	Prerelease bool      `json:"prerelease"`
+	Assets     []Asset   `json:"assets"`
+
+	assetsViaAPI bool
 }
 
 func (r *Release) GetName() string {
@@ -39,9 +43,20 @@ func (r *Release) GetReleaseNotes() string {
 	return r.Body
 }
 
+func (r *Release) GetAssets() []release.Asset {
+	assets := make([]release.Asset, 0, len(r.Assets))
+	for i := range r.Assets {
+		r.Assets[i].viaAPI = r.assetsViaAPI
+		assets = append(assets, &r.Assets[i])
+	}
+
+	return assets
+}
+
 func (r *Release) FindAsset(name string) (release.Asset, bool) {
 	for _, asset := range r.Assets {
 		if asset.Name == name {
+			asset.viaAPI = r.assetsViaAPI
 			return &asset, true
 		}
 	}
@@ -52,3 +67,11 @@ func (r *Release) FindAsset(name string) (release.Asset, bool) {
 func (r *Release) GetPublishedAt() time.Time {
 	return r.Published
 }
+
+func (r *Release) IsDraft() bool {
+	return r.Draft
+}
+
+func (r *Release) IsPrerelease() bool {
+	return r.Prerelease
+}
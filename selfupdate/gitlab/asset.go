@@ -0,0 +1,26 @@
+package gitlab
+
+type Asset struct {
+	ID             int    `json:"id"`
+	Name           string `json:"name"`
+	URL            string `json:"url"`
+	DirectAssetURL string `json:"direct_asset_url"`
+	LinkType       string `json:"link_type"`
+}
+
+func (a *Asset) GetName() string {
+	return a.Name
+}
+
+// GetSize always returns 0: release links do not carry the file size.
+func (a *Asset) GetSize() int {
+	return 0
+}
+
+func (a *Asset) GetDownloadURL() string {
+	if a.DirectAssetURL != "" {
+		return a.DirectAssetURL
+	}
+
+	return a.URL
+}
@@ -0,0 +1,191 @@
+package gitlab
+
+import (
+	"cmp"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/aatumaykin/go-self-update/selfupdate/release"
+)
+
+type (
+	Config struct {
+		APIBaseURL  string
+		Filter      string
+		Owner       string
+		Repo        string
+		HTTPClient  *http.Client
+		TokenSource release.TokenSource
+		// PackageName enables the generic package registry fallback: assets
+		// that are not attached as release links are looked up in
+		// /projects/:id/packages/generic/<PackageName>/<tag>/<asset>.
+		PackageName string
+	}
+	Client struct {
+		client      *http.Client
+		apiBaseURL  string
+		filter      string
+		owner       string
+		repo        string
+		packageName string
+		tokenSource release.TokenSource
+	}
+)
+
+const (
+	latest  = "latest"
+	perPage = 100
+)
+
+func New(config Config) *Client {
+	return &Client{
+		client:      cmp.Or(config.HTTPClient, http.DefaultClient),
+		apiBaseURL:  cmp.Or(config.APIBaseURL, "https://gitlab.com/api/v4"),
+		filter:      config.Filter,
+		owner:       config.Owner,
+		repo:        config.Repo,
+		packageName: config.PackageName,
+		tokenSource: config.TokenSource,
+	}
+}
+
+// projectID returns the URL-encoded "owner/repo" path, which GitLab accepts
+// in place of the numeric project ID. Owner may contain subgroups.
+func (c *Client) projectID() string {
+	return url.PathEscape(c.owner + "/" + c.repo)
+}
+
+// Authorize adds the access token as a Bearer token to requests sent to
+// the API host. GitLab's PRIVATE-TOKEN header is not used because
+// http.Client keeps custom headers on redirects to other hosts.
+func (c *Client) Authorize(ctx context.Context, req *http.Request) error {
+	return release.Authorize(ctx, req, c.tokenSource, c.apiBaseURL, "Bearer")
+}
+
+func (c *Client) GetVersionUrl(version string) string {
+	if version == latest {
+		return fmt.Sprintf("/projects/%s/releases/permalink/latest", c.projectID())
+	}
+
+	return c.tagPath(version)
+}
+
+func (c *Client) tagPath(tag string) string {
+	return fmt.Sprintf("/projects/%s/releases/%s", c.projectID(), url.PathEscape(tag))
+}
+
+// GetRelease returns the latest release for "latest" and the release with
+// the given tag otherwise.
+func (c *Client) GetRelease(ctx context.Context, version string) (release.Release, error) {
+	if version == latest {
+		return c.LatestRelease(ctx)
+	}
+
+	return c.ReleaseByTag(ctx, version)
+}
+
+func (c *Client) LatestRelease(ctx context.Context) (release.Release, error) {
+	return c.getRelease(ctx, c.GetVersionUrl(latest), latest)
+}
+
+func (c *Client) ReleaseByTag(ctx context.Context, tag string) (release.Release, error) {
+	return c.getRelease(ctx, c.tagPath(tag), tag)
+}
+
+func (c *Client) getRelease(ctx context.Context, path, version string) (release.Release, error) {
+	url := c.baseURL() + path
+
+	var r Release
+
+	_, err := c.request(ctx, url, &r)
+	if err != nil {
+		var statusErr *release.HTTPStatusError
+		if errors.As(err, &statusErr) && statusErr.StatusCode == http.StatusNotFound {
+			return nil, fmt.Errorf("%w: %s: %w", release.ErrReleaseNotFound, version, err)
+		}
+
+		return nil, err
+	}
+
+	if c.packageName != "" {
+		r.packageURL = c.packageURL
+	}
+
+	return &r, nil
+}
+
+func (c *Client) baseURL() string {
+	return strings.TrimSuffix(c.apiBaseURL, "/")
+}
+
+func (c *Client) packageURL(version, name string) string {
+	return fmt.Sprintf("%s/projects/%s/packages/generic/%s/%s/%s",
+		c.baseURL(), c.projectID(), url.PathEscape(c.packageName), url.PathEscape(version), url.PathEscape(name))
+}
+
+// ListReleases returns all releases of the repository, following the
+// pagination links of the API.
+func (c *Client) ListReleases(ctx context.Context) ([]release.Release, error) {
+	url := fmt.Sprintf("%s/projects/%s/releases?per_page=%d", c.baseURL(), c.projectID(), perPage)
+
+	var releases []release.Release
+
+	for url != "" {
+		var page []Release
+
+		header, err := c.request(ctx, url, &page)
+		if err != nil {
+			return nil, err
+		}
+
+		for i := range page {
+			if c.packageName != "" {
+				page[i].packageURL = c.packageURL
+			}
+			releases = append(releases, &page[i])
+		}
+
+		url = release.NextPageURL(header)
+	}
+
+	return releases, nil
+}
+
+func (c *Client) request(ctx context.Context, url string, v interface{}) (http.Header, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create HTTP request: %w", err)
+	}
+	req.Header.Add("Accept", "application/json")
+
+	if err := c.Authorize(ctx, req); err != nil {
+		return nil, err
+	}
+
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to send HTTP request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if err := release.CheckResponse(resp); err != nil {
+		return nil, err
+	}
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to GET %v: %w", url, err)
+	}
+
+	if err := json.Unmarshal(body, &v); err != nil {
+		return nil, fmt.Errorf("failed to GET %v: %w", url, err)
+	}
+
+	return resp.Header, nil
+}
@@ -0,0 +1,97 @@
+package gitlab
+
+import (
+	"strings"
+	"time"
+
+	"github.com/aatumaykin/go-self-update/selfupdate/release"
+	"github.com/blang/semver"
+)
+
+type (
+	Release struct {
+		TagName     string    `json:"tag_name"`
+		Name        string    `json:"name"`
+		Description string    `json:"description"`
+		ReleasedAt  time.Time `json:"released_at"`
+		Upcoming    bool      `json:"upcoming_release"`
+		Assets      Assets    `json:"assets"`
+		Links       Links     `json:"_links"`
+
+		// packageURL builds the generic package URL for an asset name.
+		// It is nil unless Config.PackageName is set.
+		packageURL func(version, name string) string
+	}
+
+	Assets struct {
+		Links []Asset `json:"links"`
+	}
+
+	Links struct {
+		Self string `json:"self"`
+	}
+)
+
+func (r *Release) GetName() string {
+	return r.Name
+}
+
+func (r *Release) GetTagName() string {
+	return r.TagName
+}
+
+func (r *Release) GetVersion() semver.Version {
+	version := strings.TrimPrefix(r.TagName, "v")
+	semVer, _ := semver.Parse(version)
+
+	return semVer
+}
+
+func (r *Release) GetPageURL() string {
+	return r.Links.Self
+}
+
+func (r *Release) GetReleaseNotes() string {
+	return r.Description
+}
+
+func (r *Release) GetAssets() []release.Asset {
+	assets := make([]release.Asset, 0, len(r.Assets.Links))
+	for i := range r.Assets.Links {
+		assets = append(assets, &r.Assets.Links[i])
+	}
+
+	return assets
+}
+
+func (r *Release) FindAsset(name string) (release.Asset, bool) {
+	for _, asset := range r.Assets.Links {
+		if asset.Name == name {
+			return &asset, true
+		}
+	}
+
+	if r.packageURL != nil {
+		return &Asset{
+			Name: name,
+			URL:  r.packageURL(r.TagName, name),
+		}, true
+	}
+
+	return nil, false
+}
+
+func (r *Release) GetPublishedAt() time.Time {
+	return r.ReleasedAt
+}
+
+// IsDraft reports whether the release is scheduled for a future date.
+func (r *Release) IsDraft() bool {
+	return r.Upcoming
+}
+
+// IsPrerelease always returns false: GitLab has no prerelease flag, so
+// prereleases are recognised by their semantic version only.
+func (r *Release) IsPrerelease() bool {
+	return false
+}
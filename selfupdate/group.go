@@ -0,0 +1,251 @@
+package selfupdate
+
+import (
+	"cmp"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+
+	"github.com/inconshreveable/go-update"
+)
+
+type (
+	// Target maps a release asset, or an entry of an archive asset, to a
+	// file replaced by UpdateTargets.
+	Target struct {
+		// Asset is the name template of the release asset. It is rendered
+		// with the values the Filter matched, including OS and Arch, and
+		// {{.Asset}} (the matched asset name). Defaults to the matched
+		// asset.
+		Asset string
+		// Executable is the path or template of the file inside archive
+		// assets. Defaults to the base name of Path.
+		Executable string
+		// Path is the file to replace. It is created if it does not exist.
+		Path string
+		// Mode is the mode of the new file. Defaults to the mode of the
+		// replaced file, or 0755 for a created one.
+		Mode os.FileMode
+	}
+
+	// appliedTarget records how to undo the replacement of path: restore
+	// old, or remove path if it was created.
+	appliedTarget struct {
+		path string
+		old  string
+	}
+)
+
+// UpdateTargets installs rel to several files as a group, e.g. an
+// executable and its companion binaries. All targets are downloaded and
+// verified before any file is replaced, and if replacing one of them fails,
+// the ones already replaced are restored. Validation runs against the first
+// target.
+func (u *Updater) UpdateTargets(ctx context.Context, rel *Release, targets []Target) error {
+	if err := u.checkDowngrade(rel); err != nil {
+		return err
+	}
+
+	if len(targets) == 0 {
+		return errors.New("no update targets")
+	}
+
+	prepared := make([]*preparedUpdate, 0, len(targets))
+	staged := make(map[string]struct{}, len(targets))
+
+	defer func() {
+		for _, p := range prepared {
+			_ = p.Close()
+		}
+
+		for path := range staged {
+			removeStaged(path)
+		}
+	}()
+
+	for i, t := range targets {
+		if t.Path == "" {
+			return fmt.Errorf("update target %d has no path", i)
+		}
+
+		trel, err := u.targetRelease(rel, t)
+		if err != nil {
+			return err
+		}
+
+		// Several targets may be entries of one archive, keep it staged
+		// so that it is downloaded once.
+		p, err := u.prepare(ctx, trel, t.Path, cmp.Or(t.Executable, filepath.Base(t.Path)), true)
+		if err != nil {
+			return err
+		}
+
+		if !p.patched {
+			staged[u.stagingPath(trel)] = struct{}{}
+		}
+
+		if p.entry != trel.AssetName {
+			p.checksum = nil
+		}
+
+		prepared = append(prepared, p)
+	}
+
+	applied := make([]appliedTarget, 0, len(targets))
+
+	for i, t := range targets {
+		u.logger.InfoContext(ctx, "Applying update", "path", t.Path)
+
+		a, err := applyTarget(t, prepared[i])
+		if err != nil {
+			if rerr := rollbackTargets(applied); rerr != nil {
+				return fmt.Errorf("failed to apply update to %s: %w; rollback failed: %w", t.Path, err, rerr)
+			}
+
+			return fmt.Errorf("failed to apply update to %s: %w", t.Path, err)
+		}
+
+		applied = append(applied, a)
+	}
+
+	if u.validation != nil {
+		u.logger.InfoContext(ctx, "Validating update", "path", targets[0].Path)
+
+		if err := u.validation.run(ctx, targets[0].Path); err != nil {
+			u.logger.ErrorContext(ctx, "Validation failed, rolling back", "error", err)
+
+			return &ValidationError{Err: err, RollbackErr: rollbackTargets(applied)}
+		}
+	}
+
+	for _, a := range applied {
+		if a.old != "" {
+			_ = os.Remove(a.old)
+		}
+	}
+
+	u.logger.InfoContext(ctx, "Update applied", "targets", len(targets))
+
+	return nil
+}
+
+// targetRelease returns rel narrowed to the asset of t.
+func (u *Updater) targetRelease(rel *Release, t Target) (*Release, error) {
+	if t.Asset == "" {
+		return rel, nil
+	}
+
+	name, err := renderTemplate(t.Asset, u.assetValues(rel))
+	if err != nil {
+		return nil, err
+	}
+
+	if name == rel.AssetName {
+		return rel, nil
+	}
+
+	if rel.source == nil {
+		return nil, fmt.Errorf("%w: %s (release %s was not returned by CheckVersion)", ErrAssetNotFound, name, rel.TagName)
+	}
+
+	asset := findNamedAsset(rel.source, name)
+	if asset == nil {
+		return nil, newAssetNotFoundError(rel.source, name)
+	}
+
+	trel, err := u.newRelease(rel.source, &assetMatch{asset: asset, rule: FilterRule{Template: t.Asset}, values: rel.values})
+	if err != nil {
+		return nil, err
+	}
+
+	// Patches are published for the executable only.
+	trel.PatchURL = ""
+
+	return trel, nil
+}
+
+// applyTarget replaces or creates t.Path, keeping the previous file next to
+// it until the whole group is applied.
+func applyTarget(t Target, p *preparedUpdate) (appliedTarget, error) {
+	info, err := os.Stat(t.Path)
+	if errors.Is(err, os.ErrNotExist) {
+		return appliedTarget{path: t.Path}, createTarget(t, p)
+	}
+
+	if err != nil {
+		return appliedTarget{}, err
+	}
+
+	opts := update.Options{
+		TargetPath:  t.Path,
+		TargetMode:  cmp.Or(t.Mode, info.Mode().Perm()),
+		Checksum:    p.checksum,
+		OldSavePath: oldSavePath(t.Path),
+	}
+
+	if err := update.Apply(p, opts); err != nil {
+		if rerr := update.RollbackError(err); rerr != nil {
+			return appliedTarget{}, fmt.Errorf("%w; rollback failed: %w", err, rerr)
+		}
+
+		return appliedTarget{}, err
+	}
+
+	return appliedTarget{path: t.Path, old: opts.OldSavePath}, nil
+}
+
+// createTarget writes a target that does not exist yet through a temporary
+// file, so that it never appears partially written.
+func createTarget(t Target, bin io.Reader) error {
+	dir := filepath.Dir(t.Path)
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return err
+	}
+
+	tmp := filepath.Join(dir, fmt.Sprintf(".%s.new", filepath.Base(t.Path)))
+	if err := writeFile(tmp, bin, cmp.Or(t.Mode, 0o755)); err != nil {
+		_ = os.Remove(tmp)
+		return err
+	}
+
+	if err := os.Rename(tmp, t.Path); err != nil {
+		_ = os.Remove(tmp)
+		return err
+	}
+
+	return nil
+}
+
+func writeFile(path string, r io.Reader, mode os.FileMode) error {
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, mode)
+	if err != nil {
+		return err
+	}
+
+	if _, err := io.Copy(f, r); err != nil {
+		_ = f.Close()
+		return err
+	}
+
+	return f.Close()
+}
+
+// rollbackTargets undoes applied in reverse order.
+func rollbackTargets(applied []appliedTarget) error {
+	var errs []error
+
+	for i := len(applied) - 1; i >= 0; i-- {
+		a := applied[i]
+
+		if a.old == "" {
+			errs = append(errs, os.Remove(a.path))
+		} else {
+			errs = append(errs, os.Rename(a.old, a.path))
+		}
+	}
+
+	return errors.Join(errs...)
+}
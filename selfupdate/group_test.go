@@ -0,0 +1,142 @@
+package selfupdate
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/aatumaykin/go-self-update/selfupdate/selfupdatetest"
+)
+
+func TestUpdater_UpdateTargets(t *testing.T) {
+	errCheck := errors.New("health check failed")
+
+	tests := []struct {
+		name       string
+		brokenPath bool
+		validation *Validation
+		wantNew    bool
+		wantErr    bool
+		errIs      error
+	}{
+		{
+			name:    "all targets should be replaced",
+			wantNew: true,
+		},
+		{
+			name:       "failing target should roll back the group",
+			brokenPath: true,
+			wantErr:    true,
+		},
+		{
+			name: "failed validation should roll back the group",
+			validation: &Validation{Func: func(ctx context.Context, path string) error {
+				return errCheck
+			}},
+			wantErr: true,
+			errIs:   errCheck,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			archive := gzipData(t, makeTar(t, map[string]string{
+				"agent":  "new agent",
+				"helper": "new helper",
+			}))
+
+			srv := selfupdatetest.NewServer(selfupdatetest.Config{
+				Owner: "owner",
+				Repo:  "repo",
+				Releases: []selfupdatetest.Release{{
+					Tag: "1.1.0",
+					Assets: []selfupdatetest.Asset{
+						{Name: "agent-linux-amd64.tar.gz", Content: archive},
+						{Name: "plugin-linux-amd64.so", Content: []byte("new plugin")},
+					},
+				}},
+			})
+			defer srv.Close()
+
+			u, err := New(Config{
+				APIBaseURL:     srv.APIBaseURL(),
+				Owner:          "owner",
+				Repo:           "repo",
+				CurrentVersion: "1.0.0",
+				StagingDir:     t.TempDir(),
+				Validation:     tt.validation,
+				Filter: &Filter{
+					Template: "{{.Name}}-{{.OS}}-{{.Arch}}.tar.gz",
+					Values:   map[string]string{"Name": "agent", "OS": "linux", "Arch": "amd64"},
+				},
+			})
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			ctx := context.Background()
+
+			rel, err := u.CheckVersion(ctx, "")
+			if err != nil {
+				t.Fatalf("CheckVersion() error = %v", err)
+			}
+
+			dir := t.TempDir()
+			old := map[string]string{
+				filepath.Join(dir, "agent"):        "old agent",
+				filepath.Join(dir, "agent-helper"): "old helper",
+			}
+			for path, content := range old {
+				if err := os.WriteFile(path, []byte(content), 0o755); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			targets := []Target{
+				{Path: filepath.Join(dir, "agent")},
+				{Executable: "helper", Path: filepath.Join(dir, "agent-helper")},
+				{Asset: "plugin-{{.OS}}-{{.Arch}}.so", Path: filepath.Join(dir, "plugins", "plugin.so"), Mode: 0o644},
+			}
+			if tt.brokenPath {
+				targets = append(targets, Target{Executable: "agent", Path: filepath.Join(dir, "agent", "extra")})
+			}
+
+			err = u.UpdateTargets(ctx, rel, targets)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("UpdateTargets() error = %v, wantErr %v", err, tt.wantErr)
+			}
+
+			if tt.errIs != nil && !errors.Is(err, tt.errIs) {
+				t.Errorf("UpdateTargets() error = %v, want %v", err, tt.errIs)
+			}
+
+			want := old
+			if tt.wantNew {
+				want = map[string]string{
+					filepath.Join(dir, "agent"):                "new agent",
+					filepath.Join(dir, "agent-helper"):         "new helper",
+					filepath.Join(dir, "plugins", "plugin.so"): "new plugin",
+				}
+			}
+
+			for path, content := range want {
+				got, err := os.ReadFile(path)
+				if err != nil || string(got) != content {
+					t.Errorf("%s = %q, %v, want %q", filepath.Base(path), got, err, content)
+				}
+			}
+
+			if !tt.wantNew {
+				if _, err := os.Stat(filepath.Join(dir, "plugins", "plugin.so")); !errors.Is(err, os.ErrNotExist) {
+					t.Errorf("created target was not removed: %v", err)
+				}
+			}
+
+			leftovers, _ := filepath.Glob(filepath.Join(dir, ".*.old"))
+			if len(leftovers) > 0 {
+				t.Errorf("previous versions left behind: %v", leftovers)
+			}
+		})
+	}
+}
@@ -0,0 +1,19 @@
+package local
+
+type Asset struct {
+	Name string
+	Size int
+	URL  string
+}
+
+func (a *Asset) GetName() string {
+	return a.Name
+}
+
+func (a *Asset) GetSize() int {
+	return a.Size
+}
+
+func (a *Asset) GetDownloadURL() string {
+	return a.URL
+}
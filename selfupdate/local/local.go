@@ -0,0 +1,238 @@
+package local
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/aatumaykin/go-self-update/selfupdate/manifest"
+	"github.com/aatumaykin/go-self-update/selfupdate/release"
+	"github.com/blang/semver"
+)
+
+type (
+	Config struct {
+		// Dir is the release directory, as a path or a file:// URL. It
+		// either holds a manifest.json or one directory per version named
+		// after the release tag, e.g. v1.2.0/tool-linux-amd64.
+		Dir string
+	}
+	Client struct {
+		dir string
+	}
+)
+
+const latest = "latest"
+
+func New(config Config) *Client {
+	dir := config.Dir
+	if u, err := url.Parse(dir); err == nil && u.Scheme == "file" {
+		if p, err := FilePath(u); err == nil {
+			dir = p
+		}
+	}
+
+	return &Client{dir: dir}
+}
+
+// Authorize is a no-op: local files need no credentials.
+func (c *Client) Authorize(ctx context.Context, req *http.Request) error {
+	return nil
+}
+
+func (c *Client) GetVersionUrl(version string) string {
+	if version == latest {
+		return FileURL(c.dir)
+	}
+
+	return FileURL(filepath.Join(c.dir, version))
+}
+
+// GetRelease returns the latest release for "latest" and the release with
+// the given tag otherwise.
+func (c *Client) GetRelease(ctx context.Context, version string) (release.Release, error) {
+	if version == latest {
+		return c.LatestRelease(ctx)
+	}
+
+	return c.ReleaseByTag(ctx, version)
+}
+
+// LatestRelease returns the highest stable release.
+func (c *Client) LatestRelease(ctx context.Context) (release.Release, error) {
+	m, err := c.readManifest()
+	if err != nil {
+		return nil, err
+	}
+
+	if m != nil {
+		return m.Latest()
+	}
+
+	releases, err := c.scan()
+	if err != nil {
+		return nil, err
+	}
+
+	var best *Release
+
+	for _, r := range releases {
+		if r.IsPrerelease() {
+			continue
+		}
+
+		if best == nil || r.GetVersion().GT(best.GetVersion()) {
+			best = r
+		}
+	}
+
+	if best == nil {
+		return nil, fmt.Errorf("%w: no stable release in %s", release.ErrReleaseNotFound, c.dir)
+	}
+
+	return best, nil
+}
+
+// ReleaseByTag returns the release in the directory named tag, or with the
+// given version.
+func (c *Client) ReleaseByTag(ctx context.Context, tag string) (release.Release, error) {
+	m, err := c.readManifest()
+	if err != nil {
+		return nil, err
+	}
+
+	if m != nil {
+		return m.Tag(tag)
+	}
+
+	releases, err := c.scan()
+	if err != nil {
+		return nil, err
+	}
+
+	for _, r := range releases {
+		if r.TagName == tag || r.GetVersion().String() == strings.TrimPrefix(tag, "v") {
+			return r, nil
+		}
+	}
+
+	return nil, fmt.Errorf("%w: %s in %s", release.ErrReleaseNotFound, tag, c.dir)
+}
+
+func (c *Client) ListReleases(ctx context.Context) ([]release.Release, error) {
+	m, err := c.readManifest()
+	if err != nil {
+		return nil, err
+	}
+
+	var releases []release.Release
+
+	if m != nil {
+		for i := range m.Releases {
+			releases = append(releases, &m.Releases[i])
+		}
+
+		return releases, nil
+	}
+
+	scanned, err := c.scan()
+	if err != nil {
+		return nil, err
+	}
+
+	for _, r := range scanned {
+		releases = append(releases, r)
+	}
+
+	return releases, nil
+}
+
+// readManifest returns nil if the directory has no manifest.
+func (c *Client) readManifest() (*manifest.Manifest, error) {
+	data, err := os.ReadFile(filepath.Join(c.dir, manifest.FileName))
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read manifest: %w", err)
+	}
+
+	base, err := url.Parse(FileURL(c.dir) + "/")
+	if err != nil {
+		return nil, err
+	}
+
+	return manifest.Parse(data, base)
+}
+
+// scan returns a release for every subdirectory named after a version.
+func (c *Client) scan() ([]*Release, error) {
+	entries, err := os.ReadDir(c.dir)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read release directory: %w", err)
+	}
+
+	var releases []*Release
+
+	for _, entry := range entries {
+		if !entry.IsDir() {
+			continue
+		}
+
+		if _, err := semver.Parse(strings.TrimPrefix(entry.Name(), "v")); err != nil {
+			continue
+		}
+
+		r, err := c.readRelease(entry.Name())
+		if err != nil {
+			return nil, err
+		}
+
+		releases = append(releases, r)
+	}
+
+	return releases, nil
+}
+
+func (c *Client) readRelease(tag string) (*Release, error) {
+	dir := filepath.Join(c.dir, tag)
+
+	info, err := os.Stat(dir)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read release %s: %w", tag, err)
+	}
+
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read release %s: %w", tag, err)
+	}
+
+	r := &Release{
+		TagName:   tag,
+		Published: info.ModTime(),
+	}
+
+	for _, entry := range entries {
+		if !entry.Type().IsRegular() {
+			continue
+		}
+
+		info, err := entry.Info()
+		if err != nil {
+			return nil, fmt.Errorf("failed to read release %s: %w", tag, err)
+		}
+
+		r.Assets = append(r.Assets, Asset{
+			Name: entry.Name(),
+			Size: int(info.Size()),
+			URL:  FileURL(filepath.Join(dir, entry.Name())),
+		})
+	}
+
+	return r, nil
+}
@@ -0,0 +1,69 @@
+package local
+
+import (
+	"strings"
+	"time"
+
+	"github.com/aatumaykin/go-self-update/selfupdate/release"
+	"github.com/blang/semver"
+)
+
+type Release struct {
+	TagName   string
+	Published time.Time
+	Assets    []Asset
+}
+
+func (r *Release) GetName() string {
+	return r.TagName
+}
+
+func (r *Release) GetTagName() string {
+	return r.TagName
+}
+
+func (r *Release) GetVersion() semver.Version {
+	version := strings.TrimPrefix(r.TagName, "v")
+	semVer, _ := semver.Parse(version)
+
+	return semVer
+}
+
+func (r *Release) GetPageURL() string {
+	return ""
+}
+
+func (r *Release) GetReleaseNotes() string {
+	return ""
+}
+
+func (r *Release) GetPublishedAt() time.Time {
+	return r.Published
+}
+
+func (r *Release) IsDraft() bool {
+	return false
+}
+
+func (r *Release) IsPrerelease() bool {
+	return len(r.GetVersion().Pre) > 0
+}
+
+func (r *Release) GetAssets() []release.Asset {
+	assets := make([]release.Asset, 0, len(r.Assets))
+	for i := range r.Assets {
+		assets = append(assets, &r.Assets[i])
+	}
+
+	return assets
+}
+
+func (r *Release) FindAsset(name string) (release.Asset, bool) {
+	for i := range r.Assets {
+		if r.Assets[i].Name == name {
+			return &r.Assets[i], true
+		}
+	}
+
+	return nil, false
+}
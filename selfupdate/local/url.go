@@ -0,0 +1,42 @@
+package local
+
+import (
+	"fmt"
+	"net/url"
+	"path/filepath"
+	"runtime"
+	"strings"
+)
+
+// FileURL returns the file:// URL of path.
+func FileURL(path string) string {
+	if abs, err := filepath.Abs(path); err == nil {
+		path = abs
+	}
+
+	p := filepath.ToSlash(path)
+	if !strings.HasPrefix(p, "/") {
+		// Windows drive letter, e.g. file:///C:/releases.
+		p = "/" + p
+	}
+
+	return (&url.URL{Scheme: "file", Path: p}).String()
+}
+
+// FilePath returns the local path of a file:// URL.
+func FilePath(u *url.URL) (string, error) {
+	if u.Scheme != "file" {
+		return "", fmt.Errorf("not a file URL: %s", u)
+	}
+
+	if u.Host != "" && u.Host != "localhost" {
+		return "", fmt.Errorf("unsupported file URL host: %s", u.Host)
+	}
+
+	p := u.Path
+	if runtime.GOOS == "windows" && len(p) > 2 && p[0] == '/' && p[2] == ':' {
+		p = p[1:]
+	}
+
+	return filepath.FromSlash(p), nil
+}
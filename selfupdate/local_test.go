@@ -0,0 +1,165 @@
+package selfupdate
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/aatumaykin/go-self-update/selfupdate/local"
+	"github.com/inconshreveable/go-update"
+)
+
+func TestUpdater_Local(t *testing.T) {
+	binary := []byte("new binary")
+	digest := sha256.Sum256(binary)
+
+	tests := []struct {
+		name        string
+		files       map[string]string
+		fileURL     bool
+		wantVersion string
+		wantErr     bool
+	}{
+		{
+			name: "version directories",
+			files: map[string]string{
+				"v0.9.0/test-linux-amd64":        "old",
+				"v1.0.0/test-linux-amd64":        string(binary),
+				"v1.0.0/checksums.txt":           hex.EncodeToString(digest[:]) + "  test-linux-amd64\n",
+				"v1.1.0-beta.1/test-linux-amd64": "beta",
+				"notes/README":                   "ignored",
+			},
+			wantVersion: "1.0.0",
+		},
+		{
+			name: "file URL",
+			files: map[string]string{
+				"1.0.0/test-linux-amd64": string(binary),
+			},
+			fileURL:     true,
+			wantVersion: "1.0.0",
+		},
+		{
+			name: "manifest",
+			files: map[string]string{
+				"manifest.json": `{"releases": [{"version": "2.0.0", "assets": [{
+					"name": "test-linux-amd64",
+					"url": "files/test-linux-amd64",
+					"sha256": "` + hex.EncodeToString(digest[:]) + `"
+				}]}]}`,
+				"files/test-linux-amd64": string(binary),
+			},
+			wantVersion: "2.0.0",
+		},
+		{
+			name: "checksum mismatch should fail",
+			files: map[string]string{
+				"v1.0.0/test-linux-amd64": "tampered",
+				"v1.0.0/checksums.txt":    hex.EncodeToString(digest[:]) + "  test-linux-amd64\n",
+			},
+			wantVersion: "1.0.0",
+			wantErr:     true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			for name, content := range tt.files {
+				path := filepath.Join(dir, filepath.FromSlash(name))
+				if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+					t.Fatal(err)
+				}
+				if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			source := dir
+			if tt.fileURL {
+				source = local.FileURL(dir)
+			}
+
+			u, err := New(Config{
+				RepositoryType: Local,
+				APIBaseURL:     source,
+				StagingDir:     t.TempDir(),
+				Filter: &Filter{
+					Template: "{{.Name}}-{{.OS}}-{{.Arch}}",
+					Values:   map[string]string{"Name": "test", "OS": "linux", "Arch": "amd64"},
+				},
+			})
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			rel, err := u.CheckVersion(context.Background(), "")
+			if err != nil {
+				t.Fatalf("CheckVersion() error = %v", err)
+			}
+
+			if rel.Version.String() != tt.wantVersion {
+				t.Errorf("CheckVersion() version = %s, want %s", rel.Version, tt.wantVersion)
+			}
+
+			if !strings.HasPrefix(rel.AssetURL, "file://") {
+				t.Errorf("CheckVersion() asset URL = %s, want a file URL", rel.AssetURL)
+			}
+
+			target := filepath.Join(t.TempDir(), "test")
+			if err := os.WriteFile(target, []byte("old binary"), 0o755); err != nil {
+				t.Fatal(err)
+			}
+
+			err = u.UpdateTo(context.Background(), rel, &update.Options{TargetPath: target})
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("UpdateTo() error = %v, wantErr %v", err, tt.wantErr)
+			}
+
+			want := string(binary)
+			if tt.wantErr {
+				want = "old binary"
+			}
+
+			got, _ := os.ReadFile(target)
+			if string(got) != want {
+				t.Errorf("UpdateTo() target = %q, want %q", got, want)
+			}
+		})
+	}
+}
+
+func TestFileTransport_Range(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "asset")
+	if err := os.WriteFile(path, []byte("0123456789"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	u, err := New(Config{StagingDir: t.TempDir()})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	rel := &Release{AssetName: "asset", AssetURL: local.FileURL(path)}
+
+	// A partial staging file is resumed from its end.
+	if err := os.MkdirAll(u.stagingDir, 0o700); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(u.stagingPath(rel), []byte("01234"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	staged, err := u.download(context.Background(), rel)
+	if err != nil {
+		t.Fatalf("download() error = %v", err)
+	}
+
+	got, _ := os.ReadFile(staged)
+	if string(got) != "0123456789" {
+		t.Errorf("download() = %q, want %q", got, "0123456789")
+	}
+}
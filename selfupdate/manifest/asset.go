@@ -0,0 +1,35 @@
+package manifest
+
+type Asset struct {
+	Name      string `json:"name"`
+	URL       string `json:"url"`
+	Size      int    `json:"size,omitempty"`
+	SHA256    string `json:"sha256,omitempty"`
+	Signature string `json:"signature,omitempty"`
+
+	sum []byte
+}
+
+func (a *Asset) GetName() string {
+	return a.Name
+}
+
+func (a *Asset) GetSize() int {
+	return a.Size
+}
+
+func (a *Asset) GetDownloadURL() string {
+	return a.URL
+}
+
+func (a *Asset) GetSHA256() []byte {
+	return a.sum
+}
+
+func (a *Asset) GetSignature() []byte {
+	if a.Signature == "" {
+		return nil
+	}
+
+	return []byte(a.Signature)
+}
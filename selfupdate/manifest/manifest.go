@@ -0,0 +1,225 @@
+package manifest
+
+import (
+	"cmp"
+	"context"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/aatumaykin/go-self-update/selfupdate/release"
+)
+
+type (
+	Config struct {
+		// APIBaseURL is the URL of the manifest, or of the directory holding
+		// manifest.json.
+		APIBaseURL  string
+		HTTPClient  *http.Client
+		TokenSource release.TokenSource
+	}
+	Client struct {
+		client      *http.Client
+		manifestURL string
+		tokenSource release.TokenSource
+	}
+
+	// Manifest lists the releases published to a static file host:
+	//
+	//	{
+	//	  "releases": [{
+	//	    "version": "1.2.0",
+	//	    "channel": "stable",
+	//	    "notes": "...",
+	//	    "assets": [{
+	//	      "name": "tool-1.2.0-linux-amd64.tar.gz",
+	//	      "url": "1.2.0/tool-1.2.0-linux-amd64.tar.gz",
+	//	      "size": 1234,
+	//	      "sha256": "<hex>",
+	//	      "signature": "<base64>"
+	//	    }]
+	//	  }]
+	//	}
+	//
+	// Asset URLs are resolved relative to the manifest URL.
+	Manifest struct {
+		Releases []Release `json:"releases"`
+	}
+)
+
+const (
+	latest = "latest"
+	// FileName is the manifest name looked up in a directory.
+	FileName = "manifest.json"
+)
+
+func New(config Config) *Client {
+	manifestURL := config.APIBaseURL
+	if !strings.HasSuffix(manifestURL, ".json") {
+		manifestURL = strings.TrimSuffix(manifestURL, "/") + "/" + FileName
+	}
+
+	return &Client{
+		client:      cmp.Or(config.HTTPClient, http.DefaultClient),
+		manifestURL: manifestURL,
+		tokenSource: config.TokenSource,
+	}
+}
+
+// Parse decodes a manifest and resolves its asset URLs against base.
+func Parse(data []byte, base *url.URL) (*Manifest, error) {
+	var m Manifest
+	if err := json.Unmarshal(data, &m); err != nil {
+		return nil, fmt.Errorf("failed to parse manifest: %w", err)
+	}
+
+	for i := range m.Releases {
+		r := &m.Releases[i]
+		if _, err := r.parseVersion(); err != nil {
+			return nil, fmt.Errorf("invalid version %q in manifest: %w", r.Version, err)
+		}
+
+		for j := range r.Assets {
+			a := &r.Assets[j]
+
+			ref, err := url.Parse(a.URL)
+			if err != nil {
+				return nil, fmt.Errorf("invalid URL for asset %s: %w", a.Name, err)
+			}
+			a.URL = base.ResolveReference(ref).String()
+
+			if a.SHA256 != "" {
+				sum, err := hex.DecodeString(a.SHA256)
+				if err != nil || len(sum) != 32 {
+					return nil, fmt.Errorf("invalid SHA-256 checksum for asset %s: %q", a.Name, a.SHA256)
+				}
+				a.sum = sum
+			}
+		}
+	}
+
+	return &m, nil
+}
+
+// Latest returns the highest stable release.
+func (m *Manifest) Latest() (release.Release, error) {
+	var best *Release
+
+	for i := range m.Releases {
+		r := &m.Releases[i]
+		if r.IsPrerelease() || len(r.GetVersion().Pre) > 0 || r.isChannel() {
+			continue
+		}
+
+		if best == nil || r.GetVersion().GT(best.GetVersion()) {
+			best = r
+		}
+	}
+
+	if best == nil {
+		return nil, fmt.Errorf("%w: no stable release in manifest", release.ErrReleaseNotFound)
+	}
+
+	return best, nil
+}
+
+// Tag returns the release with the given tag or version.
+func (m *Manifest) Tag(tag string) (release.Release, error) {
+	for i := range m.Releases {
+		r := &m.Releases[i]
+		if r.GetTagName() == tag || strings.TrimPrefix(tag, "v") == strings.TrimPrefix(r.Version, "v") {
+			return r, nil
+		}
+	}
+
+	return nil, fmt.Errorf("%w: %s", release.ErrReleaseNotFound, tag)
+}
+
+// Authorize adds the access token to requests sent to the manifest host.
+func (c *Client) Authorize(ctx context.Context, req *http.Request) error {
+	return release.Authorize(ctx, req, c.tokenSource, c.manifestURL, "Bearer")
+}
+
+func (c *Client) GetVersionUrl(version string) string {
+	return c.manifestURL
+}
+
+// GetRelease returns the latest release for "latest" and the release with
+// the given tag otherwise.
+func (c *Client) GetRelease(ctx context.Context, version string) (release.Release, error) {
+	if version == latest {
+		return c.LatestRelease(ctx)
+	}
+
+	return c.ReleaseByTag(ctx, version)
+}
+
+func (c *Client) LatestRelease(ctx context.Context) (release.Release, error) {
+	m, err := c.fetch(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	return m.Latest()
+}
+
+func (c *Client) ReleaseByTag(ctx context.Context, tag string) (release.Release, error) {
+	m, err := c.fetch(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	return m.Tag(tag)
+}
+
+func (c *Client) ListReleases(ctx context.Context) ([]release.Release, error) {
+	m, err := c.fetch(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	releases := make([]release.Release, 0, len(m.Releases))
+	for i := range m.Releases {
+		releases = append(releases, &m.Releases[i])
+	}
+
+	return releases, nil
+}
+
+func (c *Client) fetch(ctx context.Context) (*Manifest, error) {
+	base, err := url.Parse(c.manifestURL)
+	if err != nil {
+		return nil, fmt.Errorf("invalid manifest URL: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, c.manifestURL, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create HTTP request: %w", err)
+	}
+	req.Header.Add("Accept", "application/json")
+
+	if err := c.Authorize(ctx, req); err != nil {
+		return nil, err
+	}
+
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to send HTTP request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if err := release.CheckResponse(resp); err != nil {
+		return nil, err
+	}
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to GET %v: %w", c.manifestURL, err)
+	}
+
+	return Parse(body, base)
+}
@@ -0,0 +1,92 @@
+package manifest
+
+import (
+	"strings"
+	"time"
+
+	"github.com/aatumaykin/go-self-update/selfupdate/release"
+	"github.com/blang/semver"
+)
+
+type Release struct {
+	Version    string    `json:"version"`
+	Tag        string    `json:"tag,omitempty"`
+	Name       string    `json:"name,omitempty"`
+	Channel    string    `json:"channel,omitempty"`
+	Prerelease bool      `json:"prerelease,omitempty"`
+	Notes      string    `json:"notes,omitempty"`
+	URL        string    `json:"page_url,omitempty"`
+	Published  time.Time `json:"published_at,omitempty"`
+	Assets     []Asset   `json:"assets"`
+}
+
+func (r *Release) parseVersion() (semver.Version, error) {
+	return semver.Parse(strings.TrimPrefix(r.Version, "v"))
+}
+
+// isChannel reports whether the release is published to a channel other
+// than stable.
+func (r *Release) isChannel() bool {
+	return r.Channel != "" && r.Channel != "stable"
+}
+
+func (r *Release) GetName() string {
+	return r.Name
+}
+
+func (r *Release) GetTagName() string {
+	if r.Tag != "" {
+		return r.Tag
+	}
+
+	return r.Version
+}
+
+func (r *Release) GetVersion() semver.Version {
+	semVer, _ := r.parseVersion()
+
+	return semVer
+}
+
+func (r *Release) GetPageURL() string {
+	return r.URL
+}
+
+func (r *Release) GetReleaseNotes() string {
+	return r.Notes
+}
+
+func (r *Release) GetPublishedAt() time.Time {
+	return r.Published
+}
+
+func (r *Release) IsDraft() bool {
+	return false
+}
+
+func (r *Release) IsPrerelease() bool {
+	return r.Prerelease
+}
+
+func (r *Release) GetChannel() string {
+	return r.Channel
+}
+
+func (r *Release) GetAssets() []release.Asset {
+	assets := make([]release.Asset, 0, len(r.Assets))
+	for i := range r.Assets {
+		assets = append(assets, &r.Assets[i])
+	}
+
+	return assets
+}
+
+func (r *Release) FindAsset(name string) (release.Asset, bool) {
+	for i := range r.Assets {
+		if r.Assets[i].Name == name {
+			return &r.Assets[i], true
+		}
+	}
+
+	return nil, false
+}
@@ -0,0 +1,124 @@
+package selfupdate
+
+import (
+	"context"
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/inconshreveable/go-update"
+)
+
+func TestUpdater_Manifest(t *testing.T) {
+	binary := []byte("new binary")
+	digest := sha256.Sum256(binary)
+
+	pub, priv, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	sig := base64.StdEncoding.EncodeToString(ed25519.Sign(priv, digest[:]))
+
+	tests := []struct {
+		name        string
+		config      Config
+		sha256      string
+		wantVersion string
+		wantErr     error
+	}{
+		{
+			name:        "latest stable release",
+			sha256:      hex.EncodeToString(digest[:]),
+			wantVersion: "1.0.0",
+		},
+		{
+			name:        "beta channel with non-semver tag",
+			config:      Config{Channel: "beta"},
+			sha256:      hex.EncodeToString(digest[:]),
+			wantVersion: "1.1.0",
+		},
+		{
+			name:        "signature listed in manifest",
+			config:      Config{PublicKey: pub},
+			sha256:      hex.EncodeToString(digest[:]),
+			wantVersion: "1.0.0",
+		},
+		{
+			name:        "checksum mismatch should fail",
+			sha256:      hex.EncodeToString(make([]byte, 32)),
+			wantVersion: "1.0.0",
+			wantErr:     ErrChecksumMismatch,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			asset := func(version string) string {
+				return `{
+					"name": "test-linux-amd64",
+					"url": "files/` + version + `/test-linux-amd64",
+					"size": 10,
+					"sha256": "` + tt.sha256 + `",
+					"signature": "` + sig + `"
+				}`
+			}
+
+			mux := http.NewServeMux()
+			mux.HandleFunc("/releases/manifest.json", func(w http.ResponseWriter, r *http.Request) {
+				_, _ = w.Write([]byte(`{"releases": [
+					{"version": "0.9.0", "assets": [` + asset("0.9.0") + `]},
+					{"version": "1.0.0", "channel": "stable", "notes": "Fixes", "assets": [` + asset("1.0.0") + `]},
+					{"version": "1.1.0", "tag": "release-1.1.0", "channel": "beta", "assets": [` + asset("1.1.0") + `]}
+				]}`))
+			})
+			mux.HandleFunc("/releases/files/", func(w http.ResponseWriter, r *http.Request) {
+				_, _ = w.Write(binary)
+			})
+			srv := httptest.NewServer(mux)
+			defer srv.Close()
+
+			config := tt.config
+			config.RepositoryType = Manifest
+			config.APIBaseURL = srv.URL + "/releases/"
+			config.Filter = &Filter{
+				Template: "{{.Name}}-{{.OS}}-{{.Arch}}",
+				Values:   map[string]string{"Name": "test", "OS": "linux", "Arch": "amd64"},
+			}
+
+			u, err := New(config)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			rel, err := u.CheckVersion(context.Background(), "")
+			if err != nil {
+				t.Fatalf("CheckVersion() error = %v", err)
+			}
+
+			if rel.Version.String() != tt.wantVersion {
+				t.Errorf("CheckVersion() version = %s, want %s", rel.Version, tt.wantVersion)
+			}
+
+			if want := srv.URL + "/releases/files/" + tt.wantVersion + "/test-linux-amd64"; rel.AssetURL != want {
+				t.Errorf("CheckVersion() asset URL = %s, want %s", rel.AssetURL, want)
+			}
+
+			target := filepath.Join(t.TempDir(), "test")
+			if err := os.WriteFile(target, []byte("old binary"), 0o755); err != nil {
+				t.Fatal(err)
+			}
+
+			err = u.UpdateTo(context.Background(), rel, &update.Options{TargetPath: target})
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("UpdateTo() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
@@ -0,0 +1,252 @@
+package selfupdate
+
+import (
+	"cmp"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/blang/semver"
+)
+
+type (
+	// Notice configures CheckInBackground.
+	Notice struct {
+		// StateFile records the last check. Defaults to a file in the user
+		// cache directory ($XDG_CACHE_HOME on Linux) named after Owner and
+		// Repo, or after APIBaseURL if Repo is empty. Required if both are
+		// empty.
+		StateFile string
+		// Interval between checks. Defaults to 24 hours.
+		Interval time.Duration
+		// OptOutEnv disables the notice when set to a non-empty value.
+		// Defaults to SELFUPDATE_NO_UPDATE_NOTIFIER.
+		OptOutEnv string
+		// Output is the file the notice is printed to; no check is made
+		// when it is not a terminal. Defaults to os.Stderr.
+		Output *os.File
+		// Force checks in CI and when Output is not a terminal.
+		Force bool
+	}
+
+	// UpdateNotice is the result of CheckInBackground.
+	UpdateNotice struct {
+		done  chan struct{}
+		check *UpdateCheck
+		err   error
+	}
+
+	// noticeState is the last check attempt, the latest release it found
+	// and when that release was last reported.
+	noticeState struct {
+		CheckedAt     time.Time `json:"checked_at"`
+		NotifiedAt    time.Time `json:"notified_at,omitempty"`
+		LatestVersion string    `json:"latest_version,omitempty"`
+		LatestTag     string    `json:"latest_tag,omitempty"`
+		PageURL       string    `json:"page_url,omitempty"`
+	}
+)
+
+const defaultOptOutEnv = "SELFUPDATE_NO_UPDATE_NOTIFIER"
+
+// ciEnv are variables set by common CI systems.
+var ciEnv = []string{
+	"CI",
+	"BUILD_NUMBER",
+	"RUN_ID",
+	"GITHUB_ACTIONS",
+	"GITLAB_CI",
+	"TF_BUILD",
+	"JENKINS_URL",
+	"TEAMCITY_VERSION",
+}
+
+// CheckInBackground checks for an update at most once per Notice.Interval
+// without blocking the caller. The program typically waits for the result
+// at exit and prints a notice if an update is available. An update is
+// reported at most once per interval, and a failed check is not repeated
+// within the interval either.
+func (u *Updater) CheckInBackground(ctx context.Context) *UpdateNotice {
+	n := &UpdateNotice{done: make(chan struct{})}
+
+	notice := cmp.Or(u.notice, &Notice{})
+	if notice.suppressed() {
+		close(n.done)
+		return n
+	}
+
+	stateFile, err := u.noticeStateFile(notice)
+	if err != nil {
+		n.err = err
+		close(n.done)
+		return n
+	}
+
+	interval := cmp.Or(notice.Interval, 24*time.Hour)
+
+	state := readNoticeState(stateFile)
+	if time.Since(state.CheckedAt) < interval {
+		if time.Since(state.NotifiedAt) >= interval {
+			n.check = u.cachedCheck(state)
+		}
+
+		if n.check != nil {
+			state.NotifiedAt = time.Now()
+			u.writeNoticeState(ctx, stateFile, state)
+		}
+
+		close(n.done)
+		return n
+	}
+
+	go func() {
+		defer close(n.done)
+
+		// Record the attempt even if it fails, so that an offline machine
+		// does not check again on every run.
+		state.CheckedAt = time.Now()
+
+		check, err := u.CheckForUpdate(ctx)
+		if err != nil && !errors.Is(err, ErrNoUpdateAvailable) {
+			n.err = err
+		} else {
+			state.LatestVersion = check.Latest.Version.String()
+			state.LatestTag = check.Latest.TagName
+			state.PageURL = check.Latest.PageURL
+
+			if check.Status == UpdateAvailable {
+				n.check = check
+				state.NotifiedAt = state.CheckedAt
+			}
+		}
+
+		u.writeNoticeState(ctx, stateFile, state)
+	}()
+
+	return n
+}
+
+// cachedCheck returns the update recorded by the last check, or nil if it
+// is not newer than the running version.
+func (u *Updater) cachedCheck(state noticeState) *UpdateCheck {
+	current, ok := u.CurrentVersion()
+	if !ok {
+		return nil
+	}
+
+	latest, err := semver.Parse(state.LatestVersion)
+	if err != nil || !latest.GT(current) {
+		return nil
+	}
+
+	return &UpdateCheck{
+		Status:  UpdateAvailable,
+		Current: current,
+		Latest: &Release{
+			Version: latest,
+			TagName: state.LatestTag,
+			PageURL: state.PageURL,
+		},
+	}
+}
+
+// Done is closed when the result is available.
+func (n *UpdateNotice) Done() <-chan struct{} {
+	return n.done
+}
+
+// Wait blocks until the check is done. It returns a nil UpdateCheck if no
+// update is available or no check was made. An UpdateCheck reported from
+// the state file only has the Version, TagName and PageURL of the release.
+func (n *UpdateNotice) Wait() (*UpdateCheck, error) {
+	<-n.done
+
+	return n.check, n.err
+}
+
+func (n *Notice) suppressed() bool {
+	if os.Getenv(cmp.Or(n.OptOutEnv, defaultOptOutEnv)) != "" {
+		return true
+	}
+
+	if n.Force {
+		return false
+	}
+
+	for _, name := range ciEnv {
+		if os.Getenv(name) != "" {
+			return true
+		}
+	}
+
+	return !isTerminal(cmp.Or(n.Output, os.Stderr))
+}
+
+func (u *Updater) noticeStateFile(n *Notice) (string, error) {
+	if n.StateFile != "" {
+		return n.StateFile, nil
+	}
+
+	dir, err := os.UserCacheDir()
+	if err != nil {
+		return "", fmt.Errorf("failed to locate cache directory: %w", err)
+	}
+
+	var name string
+
+	switch {
+	case u.repo != "":
+		// GitLab owners may contain subgroups.
+		name = strings.ReplaceAll(u.owner, "/", "-") + "-" + u.repo
+	case u.apiBaseURL != "":
+		sum := sha256.Sum256([]byte(u.apiBaseURL))
+		name = hex.EncodeToString(sum[:8])
+	default:
+		return "", errors.New("notice state file is required without Repo or APIBaseURL")
+	}
+
+	return filepath.Join(dir, "go-self-update", name+".json"), nil
+}
+
+func readNoticeState(path string) noticeState {
+	var state noticeState
+
+	data, err := os.ReadFile(path)
+	if err == nil {
+		_ = json.Unmarshal(data, &state)
+	}
+
+	return state
+}
+
+func (u *Updater) writeNoticeState(ctx context.Context, path string, state noticeState) {
+	if err := writeNoticeState(path, state); err != nil {
+		u.logger.WarnContext(ctx, "Failed to write update notice state", "path", path, "error", err)
+	}
+}
+
+func writeNoticeState(path string, state noticeState) error {
+	data, err := json.Marshal(state)
+	if err != nil {
+		return err
+	}
+
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return err
+	}
+
+	return os.WriteFile(path, data, 0o644)
+}
+
+func isTerminal(f *os.File) bool {
+	info, err := f.Stat()
+
+	return err == nil && info.Mode()&os.ModeCharDevice != 0
+}
@@ -0,0 +1,165 @@
+package selfupdate
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestUpdater_CheckInBackground(t *testing.T) {
+	tests := []struct {
+		name         string
+		notice       Notice
+		env          map[string]string
+		state        *noticeState
+		fail         bool
+		wantRequests int
+		wantNotice   bool
+	}{
+		{
+			name:         "update available",
+			notice:       Notice{Force: true},
+			wantRequests: 1,
+			wantNotice:   true,
+		},
+		{
+			name:       "recorded update should be reported once without a check",
+			notice:     Notice{Force: true},
+			state:      &noticeState{CheckedAt: time.Now(), LatestVersion: "2.0.0", LatestTag: "2.0.0"},
+			wantNotice: true,
+		},
+		{
+			name:         "failed check is not repeated within the interval",
+			notice:       Notice{Force: true},
+			fail:         true,
+			wantRequests: 1,
+		},
+		{
+			name:   "opted out",
+			notice: Notice{Force: true},
+			env:    map[string]string{"SELFUPDATE_NO_UPDATE_NOTIFIER": "1"},
+		},
+		{
+			name:   "custom opt-out variable",
+			notice: Notice{Force: true, OptOutEnv: "MYTOOL_NO_UPDATE"},
+			env:    map[string]string{"MYTOOL_NO_UPDATE": "1"},
+		},
+		{
+			name: "CI",
+			env:  map[string]string{"CI": "true"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			for _, name := range ciEnv {
+				t.Setenv(name, "")
+			}
+			t.Setenv("SELFUPDATE_NO_UPDATE_NOTIFIER", "")
+			for k, v := range tt.env {
+				t.Setenv(k, v)
+			}
+
+			var requests int
+			srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				requests++
+				if tt.fail {
+					w.WriteHeader(http.StatusBadGateway)
+					return
+				}
+				_, _ = w.Write([]byte(`{"tag_name": "2.0.0", "assets": [{"name": "test-linux-amd64"}]}`))
+			}))
+			defer srv.Close()
+
+			notice := tt.notice
+			notice.StateFile = filepath.Join(t.TempDir(), "state.json")
+
+			if tt.state != nil {
+				if err := writeNoticeState(notice.StateFile, *tt.state); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			u, err := New(Config{
+				APIBaseURL:     srv.URL,
+				Owner:          "owner",
+				Repo:           "repo",
+				CurrentVersion: "1.0.0",
+				Notice:         &notice,
+				Retry:          &Retry{MaxAttempts: 1},
+				Filter: &Filter{
+					Template: "{{.Name}}-{{.OS}}-{{.Arch}}",
+					Values:   map[string]string{"Name": "test", "OS": "linux", "Arch": "amd64"},
+				},
+			})
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			// The second call within the interval neither checks nor
+			// reports the update again.
+			for i := range 2 {
+				check, err := u.CheckInBackground(context.Background()).Wait()
+				if wantErr := tt.fail && i == 0; (err != nil) != wantErr {
+					t.Fatalf("Wait() #%d error = %v, wantErr %v", i, err, wantErr)
+				}
+
+				if wantNotice := tt.wantNotice && i == 0; (check != nil) != wantNotice {
+					t.Fatalf("Wait() #%d = %v, want notice %v", i, check, wantNotice)
+				}
+				if check != nil && check.Latest.Version.String() != "2.0.0" {
+					t.Errorf("Wait() latest = %s, want 2.0.0", check.Latest.Version)
+				}
+			}
+
+			if requests != tt.wantRequests {
+				t.Errorf("requests = %d, want %d", requests, tt.wantRequests)
+			}
+		})
+	}
+}
+
+func TestUpdater_noticeStateFile(t *testing.T) {
+	tests := []struct {
+		name    string
+		config  Config
+		wantErr bool
+	}{
+		{
+			name:   "repository",
+			config: Config{Owner: "group/subgroup", Repo: "repo"},
+		},
+		{
+			name:   "manifest",
+			config: Config{RepositoryType: Manifest, APIBaseURL: "https://example.com/releases/"},
+		},
+		{
+			name:    "no repository or base URL",
+			config:  Config{RepositoryType: Manifest},
+			wantErr: true,
+		},
+	}
+
+	names := make(map[string]bool)
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			u, err := New(tt.config)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			got, err := u.noticeStateFile(&Notice{})
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("noticeStateFile() error = %v, wantErr %v", err, tt.wantErr)
+			}
+
+			if got != "" && names[got] {
+				t.Errorf("noticeStateFile() = %s, shared with another updater", got)
+			}
+			names[got] = true
+		})
+	}
+}
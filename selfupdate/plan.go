@@ -0,0 +1,93 @@
+package selfupdate
+
+import (
+	"context"
+	"fmt"
+	"io"
+
+	"github.com/blang/semver"
+	"github.com/inconshreveable/go-update"
+)
+
+// Plan describes what UpdateTo would do.
+type Plan struct {
+	// From is nil when the running version is unknown.
+	From       *semver.Version
+	To         semver.Version
+	TargetPath string
+	AssetName  string
+	AssetURL   string
+	// AssetSize is the size of the downloaded asset, or of the asset
+	// rebuilt from a patch.
+	AssetSize int64
+	// Patched is set when the asset was rebuilt from a binary patch.
+	Patched bool
+	// Executable is the archive entry that would be installed, or the
+	// asset name if the asset is not an archive.
+	Executable     string
+	ExecutableSize int64
+	// ChecksumVerified and SignatureVerified report which checks were
+	// made. Failed checks make DryRun return an error instead.
+	ChecksumVerified  bool
+	SignatureVerified bool
+	Writable          bool
+}
+
+// DryRun downloads, verifies and extracts the asset of rel like UpdateTo,
+// but does not replace the target. The downloaded asset is kept in the
+// staging directory, so a following UpdateTo does not download it again.
+// If the target is not writable, the plan is returned with the error.
+func (u *Updater) DryRun(ctx context.Context, rel *Release, updateOpts *update.Options) (*Plan, error) {
+	if err := u.checkDowngrade(rel); err != nil {
+		return nil, err
+	}
+
+	opts := update.Options{}
+	if updateOpts != nil {
+		opts = *updateOpts
+	}
+
+	var err error
+	if opts.TargetPath, err = u.resolveTarget(opts.TargetPath); err != nil {
+		return nil, err
+	}
+
+	p, err := u.prepare(ctx, rel, opts.TargetPath, u.executable, true)
+	if err != nil {
+		return nil, err
+	}
+	defer p.Close()
+
+	// Read the executable through, so that a corrupt archive fails here
+	// rather than in UpdateTo.
+	size, err := io.Copy(io.Discard, p)
+	if err != nil {
+		return nil, fmt.Errorf("failed to extract %s: %w", p.entry, err)
+	}
+
+	plan := &Plan{
+		To:                rel.Version,
+		TargetPath:        opts.TargetPath,
+		AssetName:         rel.AssetName,
+		AssetURL:          rel.AssetURL,
+		AssetSize:         p.size,
+		Patched:           p.patched,
+		Executable:        p.entry,
+		ExecutableSize:    size,
+		ChecksumVerified:  p.checksum != nil,
+		SignatureVerified: u.publicKey != nil,
+	}
+
+	if current, ok := u.CurrentVersion(); ok {
+		plan.From = &current
+	}
+
+	if err := opts.CheckPermissions(); err != nil {
+		return plan, fmt.Errorf("target %s is not writable: %w", opts.TargetPath, err)
+	}
+	plan.Writable = true
+
+	u.logger.InfoContext(ctx, "Dry run complete", "target", plan.TargetPath, "to", plan.To.String())
+
+	return plan, nil
+}
@@ -0,0 +1,158 @@
+package selfupdate
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/aatumaykin/go-self-update/selfupdate/selfupdatetest"
+	"github.com/inconshreveable/go-update"
+)
+
+func TestUpdater_DryRun(t *testing.T) {
+	binary := []byte("new binary")
+	digest := sha256.Sum256(binary)
+
+	srv := selfupdatetest.NewServer(selfupdatetest.Config{
+		Owner: "owner",
+		Repo:  "repo",
+		Releases: []selfupdatetest.Release{{
+			Tag: "1.1.0",
+			Assets: []selfupdatetest.Asset{
+				{Name: "test-linux-amd64", Content: binary},
+				{Name: "checksums.txt", Content: []byte(hex.EncodeToString(digest[:]) + "  test-linux-amd64\n")},
+			},
+		}},
+	})
+	defer srv.Close()
+
+	u, err := New(Config{
+		APIBaseURL:     srv.APIBaseURL(),
+		Owner:          "owner",
+		Repo:           "repo",
+		CurrentVersion: "1.0.0",
+		StagingDir:     t.TempDir(),
+		Filter: &Filter{
+			Template: "{{.Name}}-{{.OS}}-{{.Arch}}",
+			Values:   map[string]string{"Name": "test", "OS": "linux", "Arch": "amd64"},
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ctx := context.Background()
+
+	rel, err := u.CheckVersion(ctx, "")
+	if err != nil {
+		t.Fatalf("CheckVersion() error = %v", err)
+	}
+
+	dir := t.TempDir()
+	target := filepath.Join(dir, "test")
+	if err := os.WriteFile(target, []byte("old binary"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+
+	plan, err := u.DryRun(ctx, rel, &update.Options{TargetPath: target})
+	if err != nil {
+		t.Fatalf("DryRun() error = %v", err)
+	}
+
+	if plan.From == nil || plan.From.String() != "1.0.0" || plan.To.String() != "1.1.0" {
+		t.Errorf("DryRun() versions = %v -> %s", plan.From, plan.To)
+	}
+
+	if plan.TargetPath != target || plan.AssetSize != int64(len(binary)) || plan.Executable != "test-linux-amd64" {
+		t.Errorf("DryRun() plan = %+v", plan)
+	}
+
+	if !plan.ChecksumVerified || plan.SignatureVerified || !plan.Writable {
+		t.Errorf("DryRun() plan = %+v", plan)
+	}
+
+	got, _ := os.ReadFile(target)
+	if string(got) != "old binary" {
+		t.Errorf("DryRun() replaced the target: %q", got)
+	}
+
+	// The asset staged by the dry run is reused.
+	if err := u.UpdateTo(ctx, rel, &update.Options{TargetPath: target}); err != nil {
+		t.Fatalf("UpdateTo() error = %v", err)
+	}
+
+	if n := srv.Requests("/owner/repo/releases/download/1.1.0/test-linux-amd64"); n != 2 {
+		t.Errorf("asset requests = %d, want 2 (download and completed range check)", n)
+	}
+
+	if os.Getuid() == 0 {
+		return
+	}
+
+	if err := os.Chmod(dir, 0o555); err != nil {
+		t.Fatal(err)
+	}
+	defer os.Chmod(dir, 0o755)
+
+	plan, err = u.DryRun(ctx, rel, &update.Options{TargetPath: target})
+	if err == nil || plan == nil || plan.Writable {
+		t.Errorf("DryRun() on read-only directory = %+v, %v", plan, err)
+	}
+}
+
+func TestUpdater_DryRun_ChecksumMismatch(t *testing.T) {
+	digest := sha256.Sum256([]byte("other binary"))
+
+	srv := selfupdatetest.NewServer(selfupdatetest.Config{
+		Owner: "owner",
+		Repo:  "repo",
+		Releases: []selfupdatetest.Release{{
+			Tag: "1.1.0",
+			Assets: []selfupdatetest.Asset{
+				{Name: "test-linux-amd64", Content: []byte("new binary")},
+				{Name: "checksums.txt", Content: []byte(hex.EncodeToString(digest[:]) + "  test-linux-amd64\n")},
+			},
+		}},
+	})
+	defer srv.Close()
+
+	u, err := New(Config{
+		APIBaseURL:     srv.APIBaseURL(),
+		Owner:          "owner",
+		Repo:           "repo",
+		CurrentVersion: "1.0.0",
+		StagingDir:     t.TempDir(),
+		Filter: &Filter{
+			Template: "{{.Name}}-{{.OS}}-{{.Arch}}",
+			Values:   map[string]string{"Name": "test", "OS": "linux", "Arch": "amd64"},
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ctx := context.Background()
+
+	rel, err := u.CheckVersion(ctx, "")
+	if err != nil {
+		t.Fatalf("CheckVersion() error = %v", err)
+	}
+
+	target := filepath.Join(t.TempDir(), "test")
+
+	// Every dry run downloads the asset again instead of trusting the
+	// rejected staging file.
+	for range 2 {
+		if _, err := u.DryRun(ctx, rel, &update.Options{TargetPath: target}); !errors.Is(err, ErrChecksumMismatch) {
+			t.Fatalf("DryRun() error = %v, want ErrChecksumMismatch", err)
+		}
+
+		if _, err := os.Stat(u.stagingPath(rel)); !os.IsNotExist(err) {
+			t.Errorf("staging file was kept after a failed verification: %v", err)
+		}
+	}
+}
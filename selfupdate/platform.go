@@ -0,0 +1,123 @@
+package selfupdate
+
+import (
+	"runtime"
+	"runtime/debug"
+	"strconv"
+	"strings"
+)
+
+// osAliases and archAliases list the names release assets commonly use for
+// a GOOS and GOARCH, in order of preference. Names are matched
+// case-insensitively, so "Linux" and "linux" are the same.
+var (
+	osAliases = map[string][]string{
+		"darwin":  {"darwin", "macos", "osx", "mac"},
+		"windows": {"windows", "win"},
+	}
+
+	archAliases = map[string][]string{
+		"amd64": {"amd64", "x86_64", "x64"},
+		"arm64": {"arm64", "aarch64"},
+		"386":   {"386", "i386", "i686", "x86"},
+	}
+)
+
+func osCandidates(goos string) []string {
+	if aliases, ok := osAliases[goos]; ok {
+		return aliases
+	}
+
+	return []string{goos}
+}
+
+// archCandidates returns the names of goarch in order of preference. level
+// is the GOAMD64 or GOARM level of the running binary: assets built for a
+// lower level run too, but are tried later.
+func archCandidates(goos, goarch string, level int) []string {
+	var names []string
+
+	switch goarch {
+	case "amd64":
+		for l := level; l >= 1; l-- {
+			names = append(names, "amd64_v"+strconv.Itoa(l))
+		}
+	case "arm":
+		for l := level; l >= 5; l-- {
+			v := "armv" + strconv.Itoa(l)
+			names = append(names, v)
+
+			switch l {
+			case 7:
+				names = append(names, v+"l", "armhf")
+			case 6:
+				names = append(names, v+"l")
+			}
+		}
+	}
+
+	if aliases, ok := archAliases[goarch]; ok {
+		names = append(names, aliases...)
+	} else {
+		names = append(names, goarch)
+	}
+
+	// Universal macOS binaries.
+	if goos == "darwin" {
+		names = append(names, "all", "universal")
+	}
+
+	return names
+}
+
+// archLevel returns the GOAMD64 or GOARM level the running binary was built
+// for, e.g. 3 for GOAMD64=v3 and 7 for GOARM=7.
+func archLevel(goarch string) int {
+	key, fallback := "", 0
+
+	switch goarch {
+	case "amd64":
+		key, fallback = "GOAMD64", 1
+	case "arm":
+		key, fallback = "GOARM", 7
+	default:
+		return 0
+	}
+
+	info, ok := debug.ReadBuildInfo()
+	if !ok {
+		return fallback
+	}
+
+	for _, s := range info.Settings {
+		if s.Key != key {
+			continue
+		}
+
+		// "v3" for GOAMD64, "7" or "7,softfloat" for GOARM.
+		value, _, _ := strings.Cut(strings.TrimPrefix(s.Value, "v"), ",")
+		if l, err := strconv.Atoi(value); err == nil {
+			return l
+		}
+	}
+
+	return fallback
+}
+
+// platformCandidates returns the OS and arch names to try for the running
+// platform, unless the filter sets them explicitly.
+func platformCandidates(values map[string]string) (oses, arches []string) {
+	if v := values["OS"]; v != "" {
+		oses = []string{v}
+	} else {
+		oses = osCandidates(runtime.GOOS)
+	}
+
+	if v := values["Arch"]; v != "" {
+		arches = []string{v}
+	} else {
+		arches = archCandidates(runtime.GOOS, runtime.GOARCH, archLevel(runtime.GOARCH))
+	}
+
+	return oses, arches
+}
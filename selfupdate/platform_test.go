@@ -0,0 +1,97 @@
+package selfupdate
+
+import (
+	"runtime"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/aatumaykin/go-self-update/selfupdate/manifest"
+)
+
+func TestArchCandidates(t *testing.T) {
+	tests := []struct {
+		goos   string
+		goarch string
+		level  int
+		want   []string
+	}{
+		{"linux", "amd64", 1, []string{"amd64_v1", "amd64", "x86_64", "x64"}},
+		{"linux", "amd64", 3, []string{"amd64_v3", "amd64_v2", "amd64_v1", "amd64", "x86_64", "x64"}},
+		{"linux", "arm64", 0, []string{"arm64", "aarch64"}},
+		{"linux", "arm", 6, []string{"armv6", "armv6l", "armv5", "arm"}},
+		{"linux", "arm", 7, []string{"armv7", "armv7l", "armhf", "armv6", "armv6l", "armv5", "arm"}},
+		{"darwin", "arm64", 0, []string{"arm64", "aarch64", "all", "universal"}},
+		{"linux", "riscv64", 0, []string{"riscv64"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.goos+"/"+tt.goarch, func(t *testing.T) {
+			if got := archCandidates(tt.goos, tt.goarch, tt.level); !slices.Equal(got, tt.want) {
+				t.Errorf("archCandidates() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestUpdater_findAsset(t *testing.T) {
+	oses := osCandidates(runtime.GOOS)
+	arches := archCandidates(runtime.GOOS, runtime.GOARCH, archLevel(runtime.GOARCH))
+	alias := "tool_" + strings.ToUpper(oses[len(oses)-1]) + "_" + arches[len(arches)-1]
+
+	tests := []struct {
+		name    string
+		values  map[string]string
+		assets  []string
+		want    string
+		wantErr bool
+	}{
+		{
+			name:   "exact name preferred over alias",
+			assets: []string{alias, "tool_" + runtime.GOOS + "_" + runtime.GOARCH},
+			want:   "tool_" + runtime.GOOS + "_" + runtime.GOARCH,
+		},
+		{
+			name:   "alias matched case-insensitively",
+			assets: []string{"tool_plan9_mips", alias},
+			want:   alias,
+		},
+		{
+			name:   "explicit values disable aliases",
+			values: map[string]string{"OS": "plan9", "Arch": "mips"},
+			assets: []string{alias, "tool_plan9_mips"},
+			want:   "tool_plan9_mips",
+		},
+		{
+			name:    "no match",
+			assets:  []string{"tool_plan9_mips"},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			values := map[string]string{"Name": "tool"}
+			for k, v := range tt.values {
+				values[k] = v
+			}
+
+			u, err := New(Config{Filter: &Filter{Template: "{{.Name}}_{{.OS}}_{{.Arch}}", Values: values}})
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			r := &manifest.Release{Version: "1.0.0"}
+			for _, name := range tt.assets {
+				r.Assets = append(r.Assets, manifest.Asset{Name: name})
+			}
+
+			match, err := u.findAsset(r)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("findAsset() error = %v, wantErr %v", err, tt.wantErr)
+			}
+
+			if err == nil && match.asset.GetName() != tt.want {
+				t.Errorf("findAsset() = %s, want %s", match.asset.GetName(), tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,143 @@
+package selfupdate
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"os"
+)
+
+// preparedUpdate is a downloaded and verified asset, read as the extracted
+// executable. It must be closed.
+type preparedUpdate struct {
+	io.Reader
+	file     *os.File
+	staged   string
+	entry    string
+	checksum []byte
+	size     int64
+	patched  bool
+}
+
+// prepare downloads the asset of rel, or rebuilds it from a patch, verifies
+// its checksum and signature and opens the executable, a template of its
+// path in archive assets. The staging file is kept after Close if keep is
+// set, so that a later download of the same asset is served from it.
+func (u *Updater) prepare(ctx context.Context, rel *Release, target, executable string, keep bool) (*preparedUpdate, error) {
+	checksum, err := u.fetchChecksum(ctx, rel)
+	if err != nil {
+		return nil, err
+	}
+
+	p := &preparedUpdate{checksum: checksum}
+
+	var (
+		asset  io.ReaderAt
+		digest []byte
+		staged string
+	)
+
+	if data := u.patch(ctx, rel, target, checksum); data != nil {
+		sum := sha256.Sum256(data)
+		asset, digest = bytes.NewReader(data), sum[:]
+		p.size, p.patched = int64(len(data)), true
+	} else {
+		u.logger.InfoContext(ctx, "Downloading", "url", rel.AssetURL, "size", rel.AssetByteSize)
+
+		if staged, err = u.download(ctx, rel); err != nil {
+			return nil, err
+		}
+
+		if p.file, p.size, digest, err = openStaged(staged); err != nil {
+			removeStaged(staged)
+			return nil, err
+		}
+
+		asset = p.file
+		if !keep {
+			p.staged = staged
+		}
+	}
+
+	if err := u.unpack(ctx, rel, p, asset, digest, executable); err != nil {
+		// A kept staging file that failed verification would be served
+		// again by the next download.
+		if p.file != nil {
+			_ = p.file.Close()
+			removeStaged(staged)
+		}
+
+		return nil, err
+	}
+
+	return p, nil
+}
+
+// openStaged opens the staging file at path and hashes it.
+func openStaged(path string) (*os.File, int64, []byte, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, 0, nil, fmt.Errorf("failed to open staging file: %w", err)
+	}
+
+	h := sha256.New()
+
+	size, err := io.Copy(h, f)
+	if err != nil {
+		_ = f.Close()
+		return nil, 0, nil, fmt.Errorf("failed to read staging file: %w", err)
+	}
+
+	return f, size, h.Sum(nil), nil
+}
+
+// unpack verifies the digest and signature of the asset and opens the
+// executable in p.
+func (u *Updater) unpack(ctx context.Context, rel *Release, p *preparedUpdate, asset io.ReaderAt, digest []byte, executable string) error {
+	if checksum := p.checksum; checksum != nil {
+		if !bytes.Equal(digest, checksum) {
+			return &ChecksumMismatchError{Asset: rel.AssetName, Expected: checksum, Actual: digest}
+		}
+
+		u.logger.InfoContext(ctx, "Checksum verified", "sha256", hex.EncodeToString(checksum))
+	}
+
+	if err := u.verifySignature(ctx, rel, digest); err != nil {
+		return err
+	}
+
+	executable, err := renderTemplate(executable, u.assetValues(rel))
+	if err != nil {
+		return err
+	}
+
+	bin, entry, err := extract(rel.AssetName, asset, p.size, executable)
+	if err != nil {
+		return err
+	}
+
+	if entry != rel.AssetName {
+		u.logger.InfoContext(ctx, "Extracting", "entry", entry)
+	}
+
+	p.Reader, p.entry = bin, entry
+
+	return nil
+}
+
+// Close closes the staging file and removes it unless it is kept.
+func (p *preparedUpdate) Close() error {
+	if p.file == nil {
+		return nil
+	}
+
+	err := p.file.Close()
+	if p.staged != "" {
+		removeStaged(p.staged)
+	}
+
+	return err
+}
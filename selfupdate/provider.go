@@ -0,0 +1,120 @@
+package selfupdate
+
+import (
+	"fmt"
+	"net/http"
+	"sync"
+
+	"github.com/aatumaykin/go-self-update/selfupdate/gitea"
+	"github.com/aatumaykin/go-self-update/selfupdate/github"
+	"github.com/aatumaykin/go-self-update/selfupdate/gitlab"
+	"github.com/aatumaykin/go-self-update/selfupdate/local"
+	"github.com/aatumaykin/go-self-update/selfupdate/manifest"
+	"github.com/aatumaykin/go-self-update/selfupdate/release"
+)
+
+type (
+	// Provider is a source of releases. Custom providers are set with
+	// Config.Source or registered with RegisterProvider.
+	Provider = release.Provider
+
+	// ProviderConfig is the part of Config passed to a ProviderFactory.
+	ProviderConfig struct {
+		// HTTPClient sets the User-Agent, runs the request hooks and
+		// serves file:// URLs.
+		HTTPClient  *http.Client
+		APIBaseURL  string
+		Owner       string
+		Repo        string
+		PackageName string
+		TokenSource TokenSource
+		CacheDir    string
+	}
+
+	ProviderFactory func(config ProviderConfig) (Provider, error)
+)
+
+var (
+	providersMu sync.RWMutex
+	providers   = map[RepositoryType]ProviderFactory{
+		Github: func(config ProviderConfig) (Provider, error) {
+			return github.New(github.Config{
+				APIBaseURL:  config.APIBaseURL,
+				HTTPClient:  config.HTTPClient,
+				Owner:       config.Owner,
+				Repo:        config.Repo,
+				TokenSource: config.TokenSource,
+				CacheDir:    config.CacheDir,
+			}), nil
+		},
+		Gitea: func(config ProviderConfig) (Provider, error) {
+			return gitea.New(gitea.Config{
+				APIBaseURL:  config.APIBaseURL,
+				HTTPClient:  config.HTTPClient,
+				Owner:       config.Owner,
+				Repo:        config.Repo,
+				TokenSource: config.TokenSource,
+			}), nil
+		},
+		GitLab: func(config ProviderConfig) (Provider, error) {
+			return gitlab.New(gitlab.Config{
+				APIBaseURL:  config.APIBaseURL,
+				HTTPClient:  config.HTTPClient,
+				Owner:       config.Owner,
+				Repo:        config.Repo,
+				PackageName: config.PackageName,
+				TokenSource: config.TokenSource,
+			}), nil
+		},
+		Manifest: func(config ProviderConfig) (Provider, error) {
+			return manifest.New(manifest.Config{
+				APIBaseURL:  config.APIBaseURL,
+				HTTPClient:  config.HTTPClient,
+				TokenSource: config.TokenSource,
+			}), nil
+		},
+		Local: func(config ProviderConfig) (Provider, error) {
+			return local.New(local.Config{
+				Dir: config.APIBaseURL,
+			}), nil
+		},
+	}
+)
+
+// RegisterProvider makes a repository type available to Config.RepositoryType.
+// Registering an existing type, including a built-in one, replaces it.
+func RegisterProvider(repositoryType RepositoryType, factory ProviderFactory) {
+	if factory == nil {
+		panic("selfupdate: RegisterProvider factory is nil")
+	}
+
+	providersMu.Lock()
+	defer providersMu.Unlock()
+
+	providers[repositoryType] = factory
+}
+
+func (u *Updater) newProvider() (Provider, error) {
+	providersMu.RLock()
+	factory, ok := providers[u.repositoryType]
+	providersMu.RUnlock()
+
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", ErrUnsupportedRepository, u.repositoryType)
+	}
+
+	provider, err := factory(ProviderConfig{
+		HTTPClient:  u.httpClient,
+		APIBaseURL:  u.apiBaseURL,
+		Owner:       u.owner,
+		Repo:        u.repo,
+		PackageName: u.packageName,
+		TokenSource: u.tokenSource,
+		CacheDir:    u.cacheDir,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to create %s provider: %w", u.repositoryType, err)
+	}
+
+	return provider, nil
+}
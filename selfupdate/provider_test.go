@@ -0,0 +1,112 @@
+package selfupdate
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"testing"
+
+	"github.com/aatumaykin/go-self-update/selfupdate/manifest"
+	"github.com/aatumaykin/go-self-update/selfupdate/release"
+)
+
+type staticProvider struct {
+	releases []manifest.Release
+}
+
+func (p *staticProvider) LatestRelease(ctx context.Context) (release.Release, error) {
+	return (&manifest.Manifest{Releases: p.releases}).Latest()
+}
+
+func (p *staticProvider) ReleaseByTag(ctx context.Context, tag string) (release.Release, error) {
+	return (&manifest.Manifest{Releases: p.releases}).Tag(tag)
+}
+
+func (p *staticProvider) ListReleases(ctx context.Context) ([]release.Release, error) {
+	releases := make([]release.Release, 0, len(p.releases))
+	for i := range p.releases {
+		releases = append(releases, &p.releases[i])
+	}
+
+	return releases, nil
+}
+
+func (p *staticProvider) Authorize(ctx context.Context, req *http.Request) error {
+	return nil
+}
+
+func TestUpdater_Provider(t *testing.T) {
+	provider := &staticProvider{releases: []manifest.Release{
+		{Version: "1.0.0", Assets: []manifest.Asset{{Name: "test-linux-amd64", URL: "https://example.com/1.0.0"}}},
+		{Version: "1.1.0", Assets: []manifest.Asset{{Name: "test-linux-amd64", URL: "https://example.com/1.1.0"}}},
+		{Version: "2.0.0-rc.1", Assets: []manifest.Asset{{Name: "test-linux-amd64", URL: "https://example.com/2.0.0-rc.1"}}},
+	}}
+
+	RegisterProvider("Static", func(config ProviderConfig) (Provider, error) {
+		if config.Owner != "owner" {
+			return nil, errors.New("missing owner")
+		}
+		return provider, nil
+	})
+
+	tests := []struct {
+		name        string
+		config      Config
+		version     string
+		wantVersion string
+		wantErr     error
+	}{
+		{
+			name:        "source",
+			config:      Config{Source: provider},
+			wantVersion: "1.1.0",
+		},
+		{
+			name:        "source by tag",
+			config:      Config{Source: provider},
+			version:     "1.0.0",
+			wantVersion: "1.0.0",
+		},
+		{
+			name:        "registered provider",
+			config:      Config{RepositoryType: "Static", Owner: "owner"},
+			wantVersion: "1.1.0",
+		},
+		{
+			name:        "registered provider with channel",
+			config:      Config{RepositoryType: "Static", Owner: "owner", Channel: "beta"},
+			wantVersion: "2.0.0-rc.1",
+		},
+		{
+			name:    "unregistered provider",
+			config:  Config{RepositoryType: "Unknown"},
+			wantErr: ErrUnsupportedRepository,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			config := tt.config
+			config.Filter = &Filter{
+				Template: "{{.Name}}-{{.OS}}-{{.Arch}}",
+				Values:   map[string]string{"Name": "test", "OS": "linux", "Arch": "amd64"},
+			}
+
+			u, err := New(config)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			rel, err := u.CheckVersion(context.Background(), tt.version)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("CheckVersion() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err != nil {
+				return
+			}
+
+			if rel.Version.String() != tt.wantVersion {
+				t.Errorf("CheckVersion() version = %s, want %s", rel.Version, tt.wantVersion)
+			}
+		})
+	}
+}
@@ -0,0 +1,80 @@
+package selfupdate
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/aatumaykin/go-self-update/selfupdate/selfupdatetest"
+)
+
+func TestUpdater_ConditionalRequests(t *testing.T) {
+	srv := selfupdatetest.NewServer(selfupdatetest.Config{
+		Owner:     "owner",
+		Repo:      "repo",
+		RateLimit: 2,
+		Releases: []selfupdatetest.Release{{
+			Tag:    "1.0.0",
+			Assets: []selfupdatetest.Asset{{Name: "test-linux-amd64"}},
+		}},
+	})
+	defer srv.Close()
+
+	newUpdater := func(cacheDir string) *Updater {
+		u, err := New(Config{
+			APIBaseURL: srv.APIBaseURL(),
+			Owner:      "owner",
+			Repo:       "repo",
+			CacheDir:   cacheDir,
+			Filter: &Filter{
+				Template: "{{.Name}}-{{.OS}}-{{.Arch}}",
+				Values:   map[string]string{"Name": "test", "OS": "linux", "Arch": "amd64"},
+			},
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		return u
+	}
+
+	u := newUpdater(t.TempDir())
+
+	if _, ok := u.RateLimit(); ok {
+		t.Error("RateLimit() reported a quota before any request")
+	}
+
+	// The second check is revalidated with If-None-Match and served from
+	// the cache without using the quota.
+	for i := range 2 {
+		rel, err := u.CheckVersion(context.Background(), "")
+		if err != nil {
+			t.Fatalf("CheckVersion() #%d error = %v", i, err)
+		}
+		if rel.Version.String() != "1.0.0" {
+			t.Errorf("CheckVersion() #%d version = %s, want 1.0.0", i, rel.Version)
+		}
+	}
+
+	rl, ok := u.RateLimit()
+	if !ok || rl.Limit != 2 || rl.Remaining != 1 || rl.Reset.Unix() != 1700000000 {
+		t.Errorf("RateLimit() = %+v, %v", rl, ok)
+	}
+
+	// Without the cache, the exhausted quota is reported as a typed error.
+	u = newUpdater("")
+	if _, err := u.CheckVersion(context.Background(), ""); err != nil {
+		t.Fatalf("CheckVersion() error = %v", err)
+	}
+
+	_, err := u.CheckVersion(context.Background(), "")
+
+	var rateLimitErr *RateLimitError
+	if !errors.Is(err, ErrRateLimited) || !errors.As(err, &rateLimitErr) {
+		t.Fatalf("CheckVersion() error = %v, want %v", err, ErrRateLimited)
+	}
+
+	if rateLimitErr.Reset.Unix() != 1700000000 {
+		t.Errorf("RateLimitError.Reset = %v", rateLimitErr.Reset)
+	}
+}
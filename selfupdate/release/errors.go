@@ -0,0 +1,102 @@
+package release
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+type (
+	// HTTPStatusError is returned for unexpected HTTP response statuses.
+	HTTPStatusError struct {
+		StatusCode int
+		Status     string
+		URL        string
+	}
+
+	// RateLimitError is returned when the API rejects a request because the
+	// rate limit is exhausted. Reset is zero when the API did not say when
+	// the limit resets.
+	RateLimitError struct {
+		HTTPStatusError
+		Limit     int
+		Remaining int
+		Reset     time.Time
+	}
+)
+
+var (
+	ErrReleaseNotFound = errors.New("release not found")
+	ErrRateLimited     = errors.New("rate limit exceeded")
+)
+
+func (e *HTTPStatusError) Error() string {
+	return fmt.Sprintf("failed to GET %s: %s", e.URL, e.Status)
+}
+
+func (e *RateLimitError) Error() string {
+	if e.Reset.IsZero() {
+		return fmt.Sprintf("%s: %s", ErrRateLimited, e.HTTPStatusError.Error())
+	}
+
+	return fmt.Sprintf("%s until %s: %s", ErrRateLimited, e.Reset.Format(time.RFC3339), e.HTTPStatusError.Error())
+}
+
+func (e *RateLimitError) Is(target error) bool {
+	return target == ErrRateLimited
+}
+
+func (e *RateLimitError) Unwrap() error {
+	return &e.HTTPStatusError
+}
+
+// CheckResponse returns nil for 2xx responses, a *RateLimitError when the
+// response reports an exhausted rate limit and a *HTTPStatusError otherwise.
+func CheckResponse(resp *http.Response) error {
+	if resp.StatusCode >= 200 && resp.StatusCode < 300 {
+		return nil
+	}
+
+	statusErr := HTTPStatusError{
+		StatusCode: resp.StatusCode,
+		Status:     resp.Status,
+		URL:        resp.Request.URL.String(),
+	}
+
+	if rl, ok := parseRateLimit(resp); ok {
+		rl.HTTPStatusError = statusErr
+		return rl
+	}
+
+	return &statusErr
+}
+
+// parseRateLimit reads the rate limit and Retry-After headers of a 403 or
+// 429 response.
+func parseRateLimit(resp *http.Response) (*RateLimitError, bool) {
+	if resp.StatusCode != http.StatusForbidden && resp.StatusCode != http.StatusTooManyRequests {
+		return nil, false
+	}
+
+	rl := &RateLimitError{Remaining: -1}
+	if quota, ok := ParseRateLimit(resp.Header); ok {
+		rl.Limit, rl.Remaining, rl.Reset = quota.Limit, quota.Remaining, quota.Reset
+	}
+
+	retryAfter := resp.Header.Get("Retry-After")
+	if secs, err := strconv.Atoi(retryAfter); err == nil {
+		rl.Reset = time.Now().Add(time.Duration(secs) * time.Second)
+	} else if t, err := http.ParseTime(retryAfter); err == nil {
+		rl.Reset = t
+	}
+
+	// A 403 is only a rate limit if the headers say so; otherwise it is a
+	// permission error.
+	if resp.StatusCode == http.StatusForbidden && rl.Remaining != 0 && retryAfter == "" {
+		return nil, false
+	}
+
+	return rl, true
+}
@@ -0,0 +1,27 @@
+package release
+
+import (
+	"net/http"
+	"strings"
+)
+
+// NextPageURL returns the rel="next" target of the Link response header, the
+// pagination scheme shared by GitHub, Gitea and GitLab, or "" on the last page.
+func NextPageURL(header http.Header) string {
+	for _, value := range header.Values("Link") {
+		for _, link := range strings.Split(value, ",") {
+			target, params, ok := strings.Cut(strings.TrimSpace(link), ";")
+			if !ok || !strings.HasPrefix(target, "<") || !strings.HasSuffix(target, ">") {
+				continue
+			}
+
+			for _, param := range strings.Split(params, ";") {
+				if strings.ReplaceAll(strings.TrimSpace(param), " ", "") == `rel="next"` {
+					return strings.Trim(target, "<>")
+				}
+			}
+		}
+	}
+
+	return ""
+}
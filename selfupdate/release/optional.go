@@ -0,0 +1,21 @@
+package release
+
+type (
+	// ChecksumAsset is implemented by assets whose source lists their
+	// SHA-256 digest, so that no checksums asset has to be downloaded.
+	ChecksumAsset interface {
+		GetSHA256() []byte
+	}
+
+	// SignedAsset is implemented by assets whose source carries their
+	// detached signature.
+	SignedAsset interface {
+		GetSignature() []byte
+	}
+
+	// ChannelRelease is implemented by releases published to a named
+	// channel instead of being marked by a prerelease version.
+	ChannelRelease interface {
+		GetChannel() string
+	}
+)
@@ -0,0 +1,17 @@
+package release
+
+import (
+	"context"
+	"net/http"
+)
+
+// Provider is a source of releases, such as a forge API or a static file
+// host.
+type Provider interface {
+	LatestRelease(ctx context.Context) (Release, error)
+	ReleaseByTag(ctx context.Context, tag string) (Release, error)
+	ListReleases(ctx context.Context) ([]Release, error)
+	// Authorize adds credentials to asset download requests that are sent
+	// to the provider's host.
+	Authorize(ctx context.Context, req *http.Request) error
+}
@@ -0,0 +1,40 @@
+package release
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// RateLimit is the API quota reported with the last response.
+type RateLimit struct {
+	Limit     int
+	Remaining int
+	Reset     time.Time
+}
+
+// ParseRateLimit reads the X-RateLimit-* (GitHub, Gitea) or RateLimit-*
+// (GitLab) response headers. It reports false if the headers are absent.
+func ParseRateLimit(header http.Header) (RateLimit, bool) {
+	get := func(name string) string {
+		if v := header.Get("X-RateLimit-" + name); v != "" {
+			return v
+		}
+
+		return header.Get("RateLimit-" + name)
+	}
+
+	remaining, err := strconv.Atoi(get("Remaining"))
+	if err != nil {
+		return RateLimit{}, false
+	}
+
+	rl := RateLimit{Remaining: remaining}
+	rl.Limit, _ = strconv.Atoi(get("Limit"))
+
+	if v, err := strconv.ParseInt(get("Reset"), 10, 64); err == nil {
+		rl.Reset = time.Unix(v, 0)
+	}
+
+	return rl, true
+}
@@ -14,6 +14,9 @@ type (
 		GetPageURL() string
 		GetReleaseNotes() string
 		GetPublishedAt() time.Time
+		IsDraft() bool
+		IsPrerelease() bool
+		GetAssets() []Asset
 		FindAsset(name string) (Asset, bool)
 	}
 
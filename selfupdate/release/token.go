@@ -0,0 +1,48 @@
+package release
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+)
+
+type (
+	// TokenSource supplies the access token sent with API requests. It is
+	// called for every request, so implementations may refresh tokens.
+	TokenSource interface {
+		Token(ctx context.Context) (string, error)
+	}
+
+	StaticToken string
+)
+
+func (t StaticToken) Token(context.Context) (string, error) {
+	return string(t), nil
+}
+
+// Authorize sets the Authorization header of req to scheme followed by the
+// token of source if req is sent to the host of baseURL. http.Client drops
+// the header when a redirect leaves that host, so other hosts, such as a CDN
+// serving release assets, never receive the token.
+func Authorize(ctx context.Context, req *http.Request, source TokenSource, baseURL, scheme string) error {
+	if source == nil {
+		return nil
+	}
+
+	base, err := url.Parse(baseURL)
+	if err != nil || base.Host != req.URL.Host {
+		return nil
+	}
+
+	token, err := source.Token(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to get access token: %w", err)
+	}
+
+	if token != "" {
+		req.Header.Set("Authorization", scheme+" "+token)
+	}
+
+	return nil
+}
@@ -0,0 +1,117 @@
+package selfupdate
+
+import (
+	"fmt"
+	"net"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// RestartOptions configures Restart.
+type RestartOptions struct {
+	// Args default to os.Args.
+	Args []string
+	// Env defaults to os.Environ().
+	Env []string
+	// Files are inherited by the new process, e.g. the files of listening
+	// sockets obtained with (*net.TCPListener).File. The new process gets
+	// them back with InheritedFiles or InheritedListeners.
+	Files []*os.File
+}
+
+// inheritedFDsEnv lists the descriptors passed by Restart, e.g. "7,9".
+const inheritedFDsEnv = "SELFUPDATE_INHERITED_FDS"
+
+// executablePath is resolved at startup: once the executable is replaced,
+// os.Executable may point to the renamed previous version on Linux.
+var executablePath, executableErr = os.Executable()
+
+// Restart replaces the running process with the executable it was started
+// from, i.e. the updated one, keeping the process ID like execve(2). It
+// only returns on failure. It is not supported on Windows.
+func Restart(opts RestartOptions) error {
+	if executableErr != nil {
+		return fmt.Errorf("failed to locate executable: %w", executableErr)
+	}
+
+	args := opts.Args
+	if len(args) == 0 {
+		args = os.Args
+	}
+
+	env := opts.Env
+	if env == nil {
+		env = os.Environ()
+	}
+	env = withoutEnv(env, inheritedFDsEnv)
+
+	fds := make([]string, 0, len(opts.Files))
+	for _, f := range opts.Files {
+		fds = append(fds, strconv.FormatUint(uint64(f.Fd()), 10))
+	}
+
+	if len(fds) > 0 {
+		env = append(env, inheritedFDsEnv+"="+strings.Join(fds, ","))
+	}
+
+	return restart(executablePath, args, env, opts.Files)
+}
+
+// InheritedFiles returns the files passed by the previous process through
+// RestartOptions.Files, in the same order.
+func InheritedFiles() ([]*os.File, error) {
+	value := os.Getenv(inheritedFDsEnv)
+	if value == "" {
+		return nil, nil
+	}
+
+	var files []*os.File
+	for i, s := range strings.Split(value, ",") {
+		fd, err := strconv.ParseUint(s, 10, 0)
+		if err != nil {
+			return nil, fmt.Errorf("invalid %s: %q", inheritedFDsEnv, value)
+		}
+
+		// Do not leak the descriptor to processes started later on.
+		closeOnExec(int(fd))
+		files = append(files, os.NewFile(uintptr(fd), fmt.Sprintf("inherited-%d", i)))
+	}
+
+	return files, nil
+}
+
+// InheritedListeners returns the listeners passed by the previous process.
+func InheritedListeners() ([]net.Listener, error) {
+	files, err := InheritedFiles()
+	if err != nil {
+		return nil, err
+	}
+
+	listeners := make([]net.Listener, 0, len(files))
+	for _, f := range files {
+		l, err := net.FileListener(f)
+		_ = f.Close()
+		if err != nil {
+			for _, l := range listeners {
+				_ = l.Close()
+			}
+
+			return nil, fmt.Errorf("failed to restore listener: %w", err)
+		}
+		listeners = append(listeners, l)
+	}
+
+	return listeners, nil
+}
+
+func withoutEnv(env []string, name string) []string {
+	result := make([]string, 0, len(env))
+	for _, kv := range env {
+		if !strings.HasPrefix(kv, name+"=") {
+			result = append(result, kv)
+		}
+	}
+
+	return result
+}
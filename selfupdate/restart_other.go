@@ -0,0 +1,15 @@
+//go:build !(linux || darwin || freebsd || netbsd || openbsd || dragonfly)
+
+package selfupdate
+
+import (
+	"fmt"
+	"os"
+	"runtime"
+)
+
+func restart(string, []string, []string, []*os.File) error {
+	return fmt.Errorf("%w on %s", ErrRestartUnsupported, runtime.GOOS)
+}
+
+func closeOnExec(int) {}
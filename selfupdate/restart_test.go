@@ -0,0 +1,72 @@
+package selfupdate
+
+import (
+	"fmt"
+	"net"
+	"os"
+	"os/exec"
+	"runtime"
+	"strings"
+	"testing"
+)
+
+const restartHelperEnv = "SELFUPDATE_RESTART_HELPER"
+
+func TestRestart(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("restart is not supported on Windows")
+	}
+
+	cmd := exec.Command(os.Args[0], "-test.run=^TestRestartHelper$", "-test.v")
+	cmd.Env = append(os.Environ(), restartHelperEnv+"=exec")
+
+	out, err := cmd.CombinedOutput()
+	if err != nil {
+		t.Fatalf("helper failed: %v\n%s", err, out)
+	}
+
+	var before, after, addrBefore, addrAfter string
+	for _, line := range strings.Split(string(out), "\n") {
+		fmt.Sscanf(line, "before pid=%s addr=%s", &before, &addrBefore)
+		fmt.Sscanf(line, "after pid=%s addr=%s", &after, &addrAfter)
+	}
+
+	if before == "" || before != after {
+		t.Errorf("pid before restart = %q, after = %q, want the same pid\n%s", before, after, out)
+	}
+
+	if addrBefore == "" || addrBefore != addrAfter {
+		t.Errorf("listener before restart = %q, after = %q, want the same address\n%s", addrBefore, addrAfter, out)
+	}
+}
+
+// TestRestartHelper runs in the process started by TestRestart.
+func TestRestartHelper(t *testing.T) {
+	switch os.Getenv(restartHelperEnv) {
+	case "exec":
+		l, err := net.Listen("tcp", "127.0.0.1:0")
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		f, err := l.(*net.TCPListener).File()
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		fmt.Printf("before pid=%d addr=%s\n", os.Getpid(), l.Addr())
+
+		env := append(withoutEnv(os.Environ(), restartHelperEnv), restartHelperEnv+"=restarted")
+		err = Restart(RestartOptions{Env: env, Files: []*os.File{f}})
+		t.Fatalf("Restart() error = %v", err)
+	case "restarted":
+		listeners, err := InheritedListeners()
+		if err != nil || len(listeners) != 1 {
+			t.Fatalf("InheritedListeners() = %v, %v", listeners, err)
+		}
+
+		fmt.Printf("after pid=%d addr=%s\n", os.Getpid(), listeners[0].Addr())
+	default:
+		t.Skip("helper process for TestRestart")
+	}
+}
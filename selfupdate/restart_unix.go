@@ -0,0 +1,28 @@
+//go:build linux || darwin || freebsd || netbsd || openbsd || dragonfly
+
+package selfupdate
+
+import (
+	"fmt"
+	"os"
+	"syscall"
+)
+
+func restart(path string, args, env []string, files []*os.File) error {
+	for _, f := range files {
+		// Clear FD_CLOEXEC so that the descriptor survives execve.
+		if _, _, errno := syscall.Syscall(syscall.SYS_FCNTL, f.Fd(), syscall.F_SETFD, 0); errno != 0 {
+			return fmt.Errorf("failed to pass %s to the new process: %w", f.Name(), errno)
+		}
+	}
+
+	if err := syscall.Exec(path, args, env); err != nil {
+		return fmt.Errorf("failed to restart %s: %w", path, err)
+	}
+
+	return nil
+}
+
+func closeOnExec(fd int) {
+	syscall.CloseOnExec(fd)
+}
@@ -0,0 +1,193 @@
+package selfupdate
+
+import (
+	"cmp"
+	"context"
+	"errors"
+	"io"
+	"log/slog"
+	"math/rand/v2"
+	"net"
+	"net/http"
+	"slices"
+	"strconv"
+	"time"
+
+	"github.com/aatumaykin/go-self-update/selfupdate/release"
+)
+
+type (
+	// Retry configures retries of API requests, asset, checksum and
+	// signature downloads after transient failures.
+	Retry struct {
+		// MaxAttempts is the number of attempts per request, including the
+		// first one. Defaults to 3, 1 disables retries.
+		MaxAttempts int
+		// InitialBackoff is the wait before the second attempt, doubled for
+		// every further attempt, with random jitter of up to half of it.
+		// Defaults to 500 milliseconds.
+		InitialBackoff time.Duration
+		// MaxBackoff caps the wait between attempts. A Retry-After header,
+		// or an exhausted rate limit, asking for a longer wait ends the
+		// retries. Defaults to 30 seconds.
+		MaxBackoff time.Duration
+		// StatusCodes are the retried response status codes. Defaults to
+		// 408, 429, 500, 502, 503 and 504.
+		StatusCodes []int
+	}
+
+	retryTransport struct {
+		base   http.RoundTripper
+		retry  *Retry
+		logger *slog.Logger
+	}
+)
+
+const (
+	defaultMaxAttempts    = 3
+	defaultInitialBackoff = 500 * time.Millisecond
+	defaultMaxRetryWait   = 30 * time.Second
+)
+
+var defaultRetryStatusCodes = []int{
+	http.StatusRequestTimeout,
+	http.StatusTooManyRequests,
+	http.StatusInternalServerError,
+	http.StatusBadGateway,
+	http.StatusServiceUnavailable,
+	http.StatusGatewayTimeout,
+}
+
+func newRetry(retry *Retry) *Retry {
+	retry = cmp.Or(retry, &Retry{})
+
+	result := &Retry{
+		MaxAttempts:    cmp.Or(retry.MaxAttempts, defaultMaxAttempts),
+		InitialBackoff: cmp.Or(retry.InitialBackoff, defaultInitialBackoff),
+		MaxBackoff:     cmp.Or(retry.MaxBackoff, defaultMaxRetryWait),
+		StatusCodes:    retry.StatusCodes,
+	}
+	if len(result.StatusCodes) == 0 {
+		result.StatusCodes = defaultRetryStatusCodes
+	}
+
+	return result
+}
+
+// backoff returns the jittered wait after the given number of failed
+// attempts.
+func (r *Retry) backoff(failures int) time.Duration {
+	wait := r.InitialBackoff
+	for i := 1; i < failures && wait < r.MaxBackoff; i++ {
+		wait *= 2
+	}
+
+	wait = min(wait, r.MaxBackoff)
+	if half := wait / 2; half > 0 {
+		wait = half + rand.N(half)
+	}
+
+	return wait
+}
+
+func (t *retryTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	// A request body can only be sent again if it can be recreated.
+	if req.Body != nil && req.Body != http.NoBody && req.GetBody == nil {
+		return t.base.RoundTrip(req)
+	}
+
+	ctx := req.Context()
+
+	for attempt := 1; ; attempt++ {
+		resp, err := t.base.RoundTrip(req)
+		if attempt >= t.retry.MaxAttempts || !t.retryable(resp, err) {
+			return resp, err
+		}
+
+		wait := t.retry.backoff(attempt)
+		attrs := []any{"url", req.URL.Redacted(), "attempt", attempt}
+
+		if err != nil {
+			attrs = append(attrs, "error", err)
+		} else {
+			attrs = append(attrs, "status", resp.StatusCode)
+
+			if after, ok := retryAfter(resp.Header, time.Now()); ok {
+				if after > t.retry.MaxBackoff {
+					return resp, nil
+				}
+
+				wait = max(wait, after)
+			}
+
+			_, _ = io.Copy(io.Discard, io.LimitReader(resp.Body, 64<<10))
+			_ = resp.Body.Close()
+		}
+
+		t.logger.WarnContext(ctx, "Request failed, retrying", append(attrs, "wait", wait)...)
+
+		if err := sleep(ctx, wait); err != nil {
+			return nil, err
+		}
+
+		if req.GetBody != nil {
+			body, err := req.GetBody()
+			if err != nil {
+				return nil, err
+			}
+
+			req = req.Clone(ctx)
+			req.Body = body
+		}
+	}
+}
+
+// retryable reports whether a request that returned resp or err is worth
+// another attempt.
+func (t *retryTransport) retryable(resp *http.Response, err error) bool {
+	if err != nil {
+		if errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+			return false
+		}
+
+		var netErr net.Error
+		return errors.As(err, &netErr) || errors.Is(err, io.ErrUnexpectedEOF) || errors.Is(err, io.EOF)
+	}
+
+	return slices.Contains(t.retry.StatusCodes, resp.StatusCode)
+}
+
+// retryAfter parses a Retry-After header in seconds or as an HTTP date.
+// Without it, the wait is until the reset of an exhausted rate limit.
+func retryAfter(h http.Header, now time.Time) (time.Duration, bool) {
+	value := h.Get("Retry-After")
+	if value == "" {
+		if rl, ok := release.ParseRateLimit(h); ok && rl.Remaining == 0 && !rl.Reset.IsZero() {
+			return max(rl.Reset.Sub(now), 0), true
+		}
+
+		return 0, false
+	}
+
+	if seconds, err := strconv.Atoi(value); err == nil && seconds >= 0 {
+		return time.Duration(seconds) * time.Second, true
+	}
+
+	if at, err := http.ParseTime(value); err == nil {
+		return max(at.Sub(now), 0), true
+	}
+
+	return 0, false
+}
+
+func sleep(ctx context.Context, d time.Duration) error {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
@@ -0,0 +1,142 @@
+package selfupdate
+
+import (
+	"context"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestRetryTransport(t *testing.T) {
+	tests := []struct {
+		name         string
+		statuses     []int
+		retryAfter   string
+		header       http.Header
+		wantStatus   int
+		wantRequests int32
+	}{
+		{
+			name:         "transient error should be retried",
+			statuses:     []int{http.StatusServiceUnavailable, http.StatusOK},
+			wantStatus:   http.StatusOK,
+			wantRequests: 2,
+		},
+		{
+			name:         "client error should not be retried",
+			statuses:     []int{http.StatusNotFound},
+			wantStatus:   http.StatusNotFound,
+			wantRequests: 1,
+		},
+		{
+			name:         "attempts should be limited",
+			statuses:     []int{http.StatusBadGateway},
+			wantStatus:   http.StatusBadGateway,
+			wantRequests: 3,
+		},
+		{
+			name:         "short retry-after should be respected",
+			statuses:     []int{http.StatusTooManyRequests, http.StatusOK},
+			retryAfter:   "0",
+			wantStatus:   http.StatusOK,
+			wantRequests: 2,
+		},
+		{
+			name:         "long retry-after should end the retries",
+			statuses:     []int{http.StatusTooManyRequests, http.StatusOK},
+			retryAfter:   "3600",
+			wantStatus:   http.StatusTooManyRequests,
+			wantRequests: 1,
+		},
+		{
+			name:     "exhausted rate limit with a late reset should end the retries",
+			statuses: []int{http.StatusTooManyRequests, http.StatusOK},
+			header: http.Header{
+				"X-Ratelimit-Remaining": {"0"},
+				"X-Ratelimit-Reset":     {strconv.FormatInt(time.Now().Add(time.Hour).Unix(), 10)},
+			},
+			wantStatus:   http.StatusTooManyRequests,
+			wantRequests: 1,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var requests atomic.Int32
+
+			srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				n := int(requests.Add(1))
+				status := tt.statuses[min(n, len(tt.statuses))-1]
+
+				if tt.retryAfter != "" {
+					w.Header().Set("Retry-After", tt.retryAfter)
+				}
+				for k, v := range tt.header {
+					w.Header()[k] = v
+				}
+				w.WriteHeader(status)
+			}))
+			defer srv.Close()
+
+			client := newHTTPClient(http.DefaultClient, defaultUserAgent, nil,
+				newRetry(&Retry{InitialBackoff: time.Millisecond}), slog.Default())
+
+			req, _ := http.NewRequestWithContext(context.Background(), http.MethodGet, srv.URL, nil)
+
+			resp, err := client.Do(req)
+			if err != nil {
+				t.Fatalf("Do() error = %v", err)
+			}
+			resp.Body.Close()
+
+			if resp.StatusCode != tt.wantStatus || requests.Load() != tt.wantRequests {
+				t.Errorf("Do() = %d after %d requests, want %d after %d", resp.StatusCode, requests.Load(), tt.wantStatus, tt.wantRequests)
+			}
+		})
+	}
+}
+
+func Test_retryAfter(t *testing.T) {
+	now := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		value  string
+		header http.Header
+		want   time.Duration
+		wantOK bool
+	}{
+		{value: "", wantOK: false},
+		{
+			header: http.Header{"Ratelimit-Remaining": {"0"}, "Ratelimit-Reset": {strconv.FormatInt(now.Add(time.Minute).Unix(), 10)}},
+			want:   time.Minute,
+			wantOK: true,
+		},
+		{
+			header: http.Header{"Ratelimit-Remaining": {"10"}, "Ratelimit-Reset": {strconv.FormatInt(now.Add(time.Minute).Unix(), 10)}},
+			wantOK: false,
+		},
+		{value: "120", want: 2 * time.Minute, wantOK: true},
+		{value: "Mon, 01 Jan 2024 12:00:30 GMT", want: 30 * time.Second, wantOK: true},
+		{value: "Mon, 01 Jan 2024 11:00:00 GMT", want: 0, wantOK: true},
+		{value: "soon", wantOK: false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.value, func(t *testing.T) {
+			h := http.Header{}
+			for k, v := range tt.header {
+				h[k] = v
+			}
+			if tt.value != "" {
+				h.Set("Retry-After", tt.value)
+			}
+
+			got, ok := retryAfter(h, now)
+			if got != tt.want || ok != tt.wantOK {
+				t.Errorf("retryAfter(%q) = %v, %v, want %v, %v", tt.value, got, ok, tt.want, tt.wantOK)
+			}
+		})
+	}
+}
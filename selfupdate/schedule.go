@@ -0,0 +1,288 @@
+package selfupdate
+
+import (
+	"cmp"
+	"context"
+	"errors"
+	"fmt"
+	"math/rand/v2"
+	"slices"
+	"time"
+
+	"github.com/blang/semver"
+	"github.com/inconshreveable/go-update"
+)
+
+type (
+	// Schedule configures Run.
+	Schedule struct {
+		// Interval between checks. Defaults to one hour.
+		Interval time.Duration
+		// Jitter is the upper bound of a random delay added to every wait,
+		// so that a fleet of daemons does not hit the API at once.
+		Jitter time.Duration
+		// MaxBackoff caps the wait after consecutive failures, which starts
+		// at one minute and doubles. Defaults to Interval.
+		MaxBackoff time.Duration
+		// Windows restrict when updates are applied. Checks are made at any
+		// time, except that a deferred update is checked again when the
+		// next window starts. An empty list allows updates at any time.
+		Windows []MaintenanceWindow
+		// AutoApply installs available updates. Otherwise Run only emits
+		// EventUpdateAvailable.
+		AutoApply bool
+		// UpdateOptions are passed to UpdateTo.
+		UpdateOptions *update.Options
+		// OnEvent and Events receive every event. Sends on Events block
+		// until the event is received or the context is done.
+		OnEvent func(Event)
+		Events  chan<- Event
+
+		// now and sleep are replaced by a fake clock in tests.
+		now   func() time.Time
+		sleep func(ctx context.Context, d time.Duration) error
+	}
+
+	// MaintenanceWindow is a daily time range, e.g. Start 2h and End 4h for
+	// 02:00-04:00. End before Start spans midnight.
+	MaintenanceWindow struct {
+		// Days the window starts on. Empty means every day.
+		Days     []time.Weekday
+		Start    time.Duration
+		End      time.Duration
+		Location *time.Location
+	}
+
+	EventType int
+
+	Event struct {
+		Type    EventType
+		Time    time.Time
+		Release *Release
+		Err     error
+	}
+)
+
+const (
+	// EventUpToDate is emitted when no newer release exists.
+	EventUpToDate EventType = iota + 1
+	// EventUpdateAvailable is emitted when AutoApply is off.
+	EventUpdateAvailable
+	// EventUpdateDeferred is emitted when an update is found outside of
+	// the maintenance windows.
+	EventUpdateDeferred
+	EventUpdated
+	EventError
+)
+
+const (
+	defaultInterval = time.Hour
+	minBackoff      = time.Minute
+)
+
+func (t EventType) String() string {
+	switch t {
+	case EventUpToDate:
+		return "up to date"
+	case EventUpdateAvailable:
+		return "update available"
+	case EventUpdateDeferred:
+		return "update deferred"
+	case EventUpdated:
+		return "updated"
+	case EventError:
+		return "error"
+	default:
+		return fmt.Sprintf("EventType(%d)", int(t))
+	}
+}
+
+// Run checks for updates until ctx is done and returns ctx.Err(). Failures
+// are reported as EventError and retried with exponential backoff.
+func (u *Updater) Run(ctx context.Context, s Schedule) error {
+	s.Interval = cmp.Or(s.Interval, defaultInterval)
+	s.MaxBackoff = cmp.Or(s.MaxBackoff, s.Interval)
+	if s.now == nil {
+		s.now = time.Now
+	}
+	if s.sleep == nil {
+		s.sleep = sleep
+	}
+
+	var (
+		installed *semver.Version
+		failures  int
+	)
+
+	wait := s.jitter()
+
+	for {
+		if err := s.sleep(ctx, wait); err != nil {
+			return err
+		}
+
+		event := u.runOnce(ctx, s, installed)
+		if event.Type == EventUpdated {
+			installed = &event.Release.Version
+		}
+
+		switch event.Type {
+		case EventError:
+			failures++
+			wait = s.backoff(failures)
+			u.logger.WarnContext(ctx, "Update check failed", "error", event.Err, "retry", wait)
+		case EventUpdateDeferred:
+			// Checking again after Interval could miss the window every
+			// time, e.g. daily checks at 10:00 and a 02:00-04:00 window.
+			failures = 0
+			wait = s.Interval + s.jitter()
+
+			now := s.now()
+			if start, ok := s.nextWindow(now); ok {
+				wait = start.Sub(now) + s.jitter()
+				u.logger.InfoContext(ctx, "Update deferred", "until", start)
+			}
+		default:
+			failures = 0
+			wait = s.Interval + s.jitter()
+		}
+
+		if err := s.emit(ctx, event); err != nil {
+			return err
+		}
+	}
+}
+
+func (u *Updater) runOnce(ctx context.Context, s Schedule, installed *semver.Version) Event {
+	event := Event{Time: s.now()}
+
+	check, err := u.CheckForUpdate(ctx)
+	switch {
+	case errors.Is(err, ErrNoUpdateAvailable):
+		event.Type = EventUpToDate
+		event.Release = check.Latest
+		return event
+	case err != nil:
+		event.Type = EventError
+		event.Err = err
+		return event
+	}
+
+	event.Release = check.Latest
+
+	switch {
+	case installed != nil && !check.Latest.Version.GT(*installed):
+		// Already installed by a previous iteration; the running process
+		// is just not restarted yet.
+		event.Type = EventUpToDate
+	case !s.AutoApply:
+		event.Type = EventUpdateAvailable
+	case !s.inWindow(event.Time):
+		event.Type = EventUpdateDeferred
+	default:
+		if err := u.UpdateTo(ctx, check.Latest, s.UpdateOptions); err != nil {
+			event.Type = EventError
+			event.Err = err
+		} else {
+			event.Type = EventUpdated
+		}
+	}
+
+	return event
+}
+
+func (s Schedule) emit(ctx context.Context, event Event) error {
+	if s.OnEvent != nil {
+		s.OnEvent(event)
+	}
+
+	if s.Events != nil {
+		select {
+		case s.Events <- event:
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+
+	return nil
+}
+
+func (s Schedule) jitter() time.Duration {
+	if s.Jitter <= 0 {
+		return 0
+	}
+
+	return rand.N(s.Jitter)
+}
+
+func (s Schedule) backoff(failures int) time.Duration {
+	wait := min(minBackoff, s.Interval)
+	for i := 1; i < failures && wait < s.MaxBackoff; i++ {
+		wait *= 2
+	}
+
+	return min(wait, s.MaxBackoff) + s.jitter()
+}
+
+func (s Schedule) inWindow(t time.Time) bool {
+	if len(s.Windows) == 0 {
+		return true
+	}
+
+	for _, w := range s.Windows {
+		if w.contains(t) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// nextWindow returns the earliest start of a window after t. ok is false if
+// no window ever opens.
+func (s Schedule) nextWindow(t time.Time) (next time.Time, ok bool) {
+	for _, w := range s.Windows {
+		if start, found := w.next(t); found && (!ok || start.Before(next)) {
+			next, ok = start, true
+		}
+	}
+
+	return next, ok
+}
+
+// next returns the first start of the window after t within a week.
+func (w MaintenanceWindow) next(t time.Time) (time.Time, bool) {
+	if w.Start == w.End {
+		return time.Time{}, false
+	}
+
+	t = t.In(cmp.Or(w.Location, time.Local))
+
+	for day := range 8 {
+		start := time.Date(t.Year(), t.Month(), t.Day()+day, 0, 0, 0, 0, t.Location()).Add(w.Start)
+		if start.After(t) && w.startsOn(start.Weekday()) {
+			return start, true
+		}
+	}
+
+	return time.Time{}, false
+}
+
+func (w MaintenanceWindow) contains(t time.Time) bool {
+	t = t.In(cmp.Or(w.Location, time.Local))
+	midnight := time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, t.Location())
+	offset := t.Sub(midnight)
+
+	if w.Start <= w.End {
+		return w.startsOn(t.Weekday()) && offset >= w.Start && offset < w.End
+	}
+
+	// The window spans midnight: it either started today or yesterday.
+	yesterday := (t.Weekday() + 6) % 7
+
+	return (w.startsOn(t.Weekday()) && offset >= w.Start) || (w.startsOn(yesterday) && offset < w.End)
+}
+
+func (w MaintenanceWindow) startsOn(day time.Weekday) bool {
+	return len(w.Days) == 0 || slices.Contains(w.Days, day)
+}
@@ -0,0 +1,290 @@
+package selfupdate
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/aatumaykin/go-self-update/selfupdate/selfupdatetest"
+	"github.com/inconshreveable/go-update"
+)
+
+func TestMaintenanceWindow_contains(t *testing.T) {
+	// 2024-05-01 is a Wednesday.
+	at := func(day, hour, minute int) time.Time {
+		return time.Date(2024, 5, day, hour, minute, 0, 0, time.UTC)
+	}
+
+	tests := []struct {
+		name   string
+		window MaintenanceWindow
+		t      time.Time
+		want   bool
+	}{
+		{
+			name:   "inside daily window",
+			window: MaintenanceWindow{Start: 2 * time.Hour, End: 4 * time.Hour, Location: time.UTC},
+			t:      at(1, 3, 0),
+			want:   true,
+		},
+		{
+			name:   "end is exclusive",
+			window: MaintenanceWindow{Start: 2 * time.Hour, End: 4 * time.Hour, Location: time.UTC},
+			t:      at(1, 4, 0),
+			want:   false,
+		},
+		{
+			name:   "wrong weekday",
+			window: MaintenanceWindow{Days: []time.Weekday{time.Sunday}, Start: 2 * time.Hour, End: 4 * time.Hour, Location: time.UTC},
+			t:      at(1, 3, 0),
+			want:   false,
+		},
+		{
+			name:   "overnight window before midnight",
+			window: MaintenanceWindow{Days: []time.Weekday{time.Wednesday}, Start: 23 * time.Hour, End: time.Hour, Location: time.UTC},
+			t:      at(1, 23, 30),
+			want:   true,
+		},
+		{
+			name:   "overnight window after midnight belongs to the previous day",
+			window: MaintenanceWindow{Days: []time.Weekday{time.Wednesday}, Start: 23 * time.Hour, End: time.Hour, Location: time.UTC},
+			t:      at(2, 0, 30),
+			want:   true,
+		},
+		{
+			name:   "overnight window started on another day",
+			window: MaintenanceWindow{Days: []time.Weekday{time.Wednesday}, Start: 23 * time.Hour, End: time.Hour, Location: time.UTC},
+			t:      at(1, 0, 30),
+			want:   false,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.window.contains(tt.t); got != tt.want {
+				t.Errorf("contains() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSchedule_backoff(t *testing.T) {
+	s := Schedule{Interval: time.Hour, MaxBackoff: 5 * time.Minute}
+
+	for failures, want := range []time.Duration{1: time.Minute, 2: 2 * time.Minute, 3: 4 * time.Minute, 4: 5 * time.Minute, 5: 5 * time.Minute} {
+		if failures == 0 {
+			continue
+		}
+
+		if got := s.backoff(failures); got != want {
+			t.Errorf("backoff(%d) = %v, want %v", failures, got, want)
+		}
+	}
+}
+
+func TestUpdater_Run(t *testing.T) {
+	var srv *httptest.Server
+	mux := http.NewServeMux()
+	mux.HandleFunc("/repos/owner/repo/releases/latest", func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(`{
+			"tag_name": "1.1.0",
+			"assets": [{"name": "test-linux-amd64", "browser_download_url": "` + srv.URL + `/test-linux-amd64"}]
+		}`))
+	})
+	mux.HandleFunc("/test-linux-amd64", func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte("new binary"))
+	})
+	srv = httptest.NewServer(mux)
+	defer srv.Close()
+
+	tests := []struct {
+		name      string
+		schedule  Schedule
+		want      []EventType
+		wantApply bool
+	}{
+		{
+			name:     "notify only",
+			schedule: Schedule{},
+			want:     []EventType{EventUpdateAvailable, EventUpdateAvailable},
+		},
+		{
+			name:     "outside maintenance window",
+			schedule: Schedule{AutoApply: true, Windows: []MaintenanceWindow{{Start: time.Hour, End: time.Hour}}},
+			want:     []EventType{EventUpdateDeferred, EventUpdateDeferred},
+		},
+		{
+			name:      "auto apply installs once",
+			schedule:  Schedule{AutoApply: true},
+			want:      []EventType{EventUpdated, EventUpToDate},
+			wantApply: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			target := filepath.Join(t.TempDir(), "test")
+			if err := os.WriteFile(target, []byte("old binary"), 0o755); err != nil {
+				t.Fatal(err)
+			}
+
+			u, _ := New(Config{
+				APIBaseURL:     srv.URL,
+				Owner:          "owner",
+				Repo:           "repo",
+				CurrentVersion: "1.0.0",
+				StagingDir:     t.TempDir(),
+				Filter: &Filter{
+					Template: "{{.Name}}-{{.OS}}-{{.Arch}}",
+					Values:   map[string]string{"Name": "test", "OS": "linux", "Arch": "amd64"},
+				},
+			})
+
+			events := make(chan Event)
+			s := tt.schedule
+			s.Interval = 10 * time.Millisecond
+			s.Events = events
+			s.UpdateOptions = &update.Options{TargetPath: target}
+
+			ctx, cancel := context.WithCancel(context.Background())
+			done := make(chan error)
+			go func() {
+				done <- u.Run(ctx, s)
+			}()
+
+			for i, want := range tt.want {
+				if e := <-events; e.Type != want {
+					t.Errorf("event %d = %v (%v), want %v", i, e.Type, e.Err, want)
+				}
+			}
+
+			cancel()
+			if err := <-done; !errors.Is(err, context.Canceled) {
+				t.Errorf("Run() error = %v, want %v", err, context.Canceled)
+			}
+
+			got, _ := os.ReadFile(target)
+			if applied := string(got) == "new binary"; applied != tt.wantApply {
+				t.Errorf("update applied = %v, want %v", applied, tt.wantApply)
+			}
+		})
+	}
+}
+
+func TestSchedule_nextWindow(t *testing.T) {
+	// 2024-01-01 is a Monday.
+	now := time.Date(2024, 1, 1, 10, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name    string
+		windows []MaintenanceWindow
+		want    time.Time
+		wantOK  bool
+	}{
+		{
+			name:    "later today",
+			windows: []MaintenanceWindow{{Start: 22 * time.Hour, End: 23 * time.Hour, Location: time.UTC}},
+			want:    time.Date(2024, 1, 1, 22, 0, 0, 0, time.UTC),
+			wantOK:  true,
+		},
+		{
+			name:    "tomorrow",
+			windows: []MaintenanceWindow{{Start: 2 * time.Hour, End: 4 * time.Hour, Location: time.UTC}},
+			want:    time.Date(2024, 1, 2, 2, 0, 0, 0, time.UTC),
+			wantOK:  true,
+		},
+		{
+			name: "earliest of several on given days",
+			windows: []MaintenanceWindow{
+				{Days: []time.Weekday{time.Saturday}, Start: time.Hour, End: 2 * time.Hour, Location: time.UTC},
+				{Days: []time.Weekday{time.Wednesday}, Start: 3 * time.Hour, End: 4 * time.Hour, Location: time.UTC},
+			},
+			want:   time.Date(2024, 1, 3, 3, 0, 0, 0, time.UTC),
+			wantOK: true,
+		},
+		{
+			name:    "empty window never opens",
+			windows: []MaintenanceWindow{{Start: time.Hour, End: time.Hour}},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok := Schedule{Windows: tt.windows}.nextWindow(now)
+			if !got.Equal(tt.want) || ok != tt.wantOK {
+				t.Errorf("nextWindow() = %v, %v, want %v, %v", got, ok, tt.want, tt.wantOK)
+			}
+		})
+	}
+}
+
+func TestUpdater_Run_DeferredUntilWindow(t *testing.T) {
+	srv := selfupdatetest.NewServer(selfupdatetest.Config{
+		Owner: "owner",
+		Repo:  "repo",
+		Releases: []selfupdatetest.Release{{
+			Tag:    "1.1.0",
+			Assets: []selfupdatetest.Asset{{Name: "test-linux-amd64", Content: []byte("new binary")}},
+		}},
+	})
+	defer srv.Close()
+
+	target := filepath.Join(t.TempDir(), "test")
+	if err := os.WriteFile(target, []byte("old binary"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+
+	u, _ := New(Config{
+		APIBaseURL:     srv.APIBaseURL(),
+		Owner:          "owner",
+		Repo:           "repo",
+		CurrentVersion: "1.0.0",
+		StagingDir:     t.TempDir(),
+		Filter: &Filter{
+			Template: "{{.Name}}-{{.OS}}-{{.Arch}}",
+			Values:   map[string]string{"Name": "test", "OS": "linux", "Arch": "amd64"},
+		},
+	})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	// A daily check that starts at 10:00 would never hit the 02:00-04:00
+	// window if it waited Interval after a deferral.
+	clock := time.Date(2024, 1, 1, 10, 0, 0, 0, time.UTC)
+
+	var events []Event
+	s := Schedule{
+		Interval:      24 * time.Hour,
+		AutoApply:     true,
+		Windows:       []MaintenanceWindow{{Start: 2 * time.Hour, End: 4 * time.Hour, Location: time.UTC}},
+		UpdateOptions: &update.Options{TargetPath: target},
+		OnEvent: func(e Event) {
+			events = append(events, e)
+			if len(events) == 2 {
+				cancel()
+			}
+		},
+		now: func() time.Time {
+			return clock
+		},
+		sleep: func(ctx context.Context, d time.Duration) error {
+			clock = clock.Add(d)
+			return ctx.Err()
+		},
+	}
+
+	if err := u.Run(ctx, s); !errors.Is(err, context.Canceled) {
+		t.Fatalf("Run() error = %v, want %v", err, context.Canceled)
+	}
+
+	if len(events) != 2 || events[0].Type != EventUpdateDeferred || events[1].Type != EventUpdated {
+		t.Fatalf("events = %v, want deferred, updated", events)
+	}
+
+	if want := time.Date(2024, 1, 2, 2, 0, 0, 0, time.UTC); !events[1].Time.Equal(want) {
+		t.Errorf("update applied at %v, want %v", events[1].Time, want)
+	}
+}
@@ -0,0 +1,207 @@
+package selfupdate
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/aatumaykin/go-self-update/selfupdate/release"
+	"github.com/blang/semver"
+)
+
+type selector struct {
+	constraint     semver.Range
+	constraintText string
+	prerelease     bool
+	channel        string
+	identifiers    []string
+}
+
+// DefaultChannels maps channel names to the prerelease identifiers they
+// accept in addition to stable releases.
+var DefaultChannels = map[string][]string{
+	"stable":  nil,
+	"beta":    {"beta", "rc"},
+	"nightly": {"nightly", "alpha", "beta", "rc"},
+}
+
+var partialVersion = regexp.MustCompile(`^(>=|<=|>|<|==|=|!=|!)?v?(\d+(?:\.\d+)?)$`)
+
+// newSelector returns nil when the config does not restrict the releases,
+// in which case "latest" is resolved by the repository itself.
+func newSelector(config Config) (*selector, error) {
+	if config.Constraint == "" && !config.Prerelease && config.Channel == "" {
+		return nil, nil
+	}
+
+	s := &selector{
+		constraintText: config.Constraint,
+		prerelease:     config.Prerelease,
+		channel:        config.Channel,
+	}
+
+	if config.Constraint != "" {
+		r, err := parseConstraint(config.Constraint)
+		if err != nil {
+			return nil, err
+		}
+		s.constraint = r
+	}
+
+	if config.Channel != "" {
+		channels := config.Channels
+		if channels == nil {
+			channels = DefaultChannels
+		}
+
+		identifiers, ok := channels[config.Channel]
+		if !ok {
+			return nil, fmt.Errorf("unknown release channel: %s", config.Channel)
+		}
+		s.identifiers = identifiers
+	}
+
+	return s, nil
+}
+
+// parseConstraint parses a semver range, allowing partial versions such as
+// ">=1.2 <2" which are padded with zeros.
+func parseConstraint(text string) (semver.Range, error) {
+	fields := strings.Fields(text)
+	for i, f := range fields {
+		m := partialVersion.FindStringSubmatch(f)
+		if m == nil {
+			continue
+		}
+
+		version := m[2]
+		for strings.Count(version, ".") < 2 {
+			version += ".0"
+		}
+		fields[i] = m[1] + version
+	}
+
+	r, err := semver.ParseRange(strings.Join(fields, " "))
+	if err != nil {
+		return nil, fmt.Errorf("invalid version constraint %q: %w", text, err)
+	}
+
+	return r, nil
+}
+
+func (s *selector) match(r release.Release) bool {
+	if r.IsDraft() {
+		return false
+	}
+
+	// Providers return the zero version for a release whose version does
+	// not parse, the tag may differ from it, e.g. release-1.2.0.
+	v := r.GetVersion()
+	if v.Equals(semver.Version{}) {
+		return false
+	}
+
+	if c, ok := r.(release.ChannelRelease); ok && c.GetChannel() != "" && c.GetChannel() != "stable" {
+		if !s.allowChannel(c.GetChannel()) {
+			return false
+		}
+	} else if (len(v.Pre) > 0 || r.IsPrerelease()) && !s.allowPrerelease(v) {
+		return false
+	}
+
+	return s.constraint == nil || s.constraint(v)
+}
+
+func (s *selector) allowPrerelease(v semver.Version) bool {
+	if s.prerelease {
+		return true
+	}
+
+	if len(v.Pre) == 0 {
+		return false
+	}
+
+	id := strings.ToLower(v.Pre[0].String())
+	for _, prefix := range s.identifiers {
+		if strings.HasPrefix(id, prefix) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// allowChannel accepts releases of a named channel that is the selected
+// channel or one of its prerelease identifiers, so that nightly includes
+// beta releases.
+func (s *selector) allowChannel(channel string) bool {
+	if s.prerelease || strings.EqualFold(channel, s.channel) {
+		return true
+	}
+
+	channel = strings.ToLower(channel)
+	for _, prefix := range s.identifiers {
+		if strings.HasPrefix(channel, prefix) {
+			return true
+		}
+	}
+
+	return false
+}
+
+func (s *selector) String() string {
+	var parts []string
+	if s.constraintText != "" {
+		parts = append(parts, "constraint "+s.constraintText)
+	}
+	if s.channel != "" {
+		parts = append(parts, "channel "+s.channel)
+	}
+	if s.prerelease {
+		parts = append(parts, "prereleases")
+	}
+
+	return strings.Join(parts, ", ")
+}
+
+// selectRelease returns the highest release accepted by the selector that
+// has an asset matching the filter.
+func (u *Updater) selectRelease(ctx context.Context, provider Provider) (release.Release, *assetMatch, error) {
+	releases, err := provider.ListReleases(ctx)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var (
+		best      release.Release
+		bestMatch *assetMatch
+	)
+
+	for _, r := range releases {
+		if !u.selector.match(r) {
+			continue
+		}
+
+		if best != nil && !r.GetVersion().GT(best.GetVersion()) {
+			continue
+		}
+
+		match, err := u.findAsset(r)
+		if errors.Is(err, ErrAssetNotFound) {
+			continue
+		}
+		if err != nil {
+			return nil, nil, err
+		}
+
+		best, bestMatch = r, match
+	}
+
+	if best == nil {
+		return nil, nil, fmt.Errorf("%w: no release matches %s", ErrReleaseNotFound, u.selector)
+	}
+
+	return best, bestMatch, nil
+}
@@ -0,0 +1,119 @@
+package selfupdate
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestUpdater_CheckVersion_Selector(t *testing.T) {
+	asset := func(tag string) string {
+		return fmt.Sprintf(`[{"name": "test-%s-linux-amd64", "browser_download_url": "https://example.com/%s"}]`, tag, tag)
+	}
+	pages := []string{
+		`[
+			{"tag_name": "v2.1.0", "draft": true, "assets": ` + asset("v2.1.0") + `},
+			{"tag_name": "v2.0.0-nightly.20240501", "assets": ` + asset("v2.0.0-nightly.20240501") + `},
+			{"tag_name": "v2.0.0-beta.1", "prerelease": true, "assets": ` + asset("v2.0.0-beta.1") + `},
+			{"tag_name": "v1.9.0", "assets": []}
+		]`,
+		`[
+			{"tag_name": "v1.2.0", "assets": ` + asset("v1.2.0") + `},
+			{"tag_name": "v1.1.0", "assets": ` + asset("v1.1.0") + `},
+			{"tag_name": "not-a-version", "assets": ` + asset("not-a-version") + `}
+		]`,
+	}
+
+	var srv *httptest.Server
+	srv = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/repos/owner/repo/releases" {
+			http.NotFound(w, r)
+			return
+		}
+
+		if r.URL.Query().Get("page") == "2" {
+			_, _ = w.Write([]byte(pages[1]))
+			return
+		}
+
+		w.Header().Set("Link", `<`+srv.URL+`/repos/owner/repo/releases?per_page=100&page=2>; rel="next", <`+srv.URL+`/repos/owner/repo/releases?per_page=100&page=2>; rel="last"`)
+		_, _ = w.Write([]byte(pages[0]))
+	}))
+	defer srv.Close()
+
+	tests := []struct {
+		name       string
+		constraint string
+		prerelease bool
+		channel    string
+		want       string
+		wantErr    error
+	}{
+		{
+			name:    "stable channel skips prereleases and releases without asset",
+			channel: "stable",
+			want:    "1.2.0",
+		},
+		{
+			name:       "constraint selects highest matching version",
+			constraint: ">=1.0 <1.2",
+			want:       "1.1.0",
+		},
+		{
+			name:    "beta channel accepts beta prereleases",
+			channel: "beta",
+			want:    "2.0.0-beta.1",
+		},
+		{
+			name:       "prerelease flag accepts any prerelease",
+			prerelease: true,
+			want:       "2.0.0-nightly.20240501",
+		},
+		{
+			name:       "nothing matches",
+			constraint: ">=3",
+			wantErr:    ErrReleaseNotFound,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			u, err := New(Config{
+				APIBaseURL: srv.URL,
+				Owner:      "owner",
+				Repo:       "repo",
+				Constraint: tt.constraint,
+				Prerelease: tt.prerelease,
+				Channel:    tt.channel,
+				Filter: &Filter{
+					Template: "{{.Name}}-{{.Version}}-{{.OS}}-{{.Arch}}",
+					Values:   map[string]string{"Name": "test", "OS": "linux", "Arch": "amd64"},
+				},
+			})
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			r, err := u.CheckVersion(context.Background(), "")
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("CheckVersion() error = %v, wantErr %v", err, tt.wantErr)
+			}
+
+			if tt.wantErr != nil {
+				return
+			}
+
+			if r.Version.String() != tt.want {
+				t.Errorf("CheckVersion() version = %v, want %v", r.Version, tt.want)
+			}
+		})
+	}
+}
+
+func TestNew_UnknownChannel(t *testing.T) {
+	if _, err := New(Config{Channel: "canary"}); err == nil {
+		t.Error("New() error = nil, want unknown channel error")
+	}
+}
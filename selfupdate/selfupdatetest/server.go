@@ -0,0 +1,474 @@
+// Package selfupdatetest provides an in-process fake GitHub or Gitea server
+// for testing update flows without network access.
+package selfupdatetest
+
+import (
+	"cmp"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/blang/semver"
+)
+
+type (
+	// Forge selects the API the server imitates.
+	Forge string
+
+	Config struct {
+		// Forge defaults to GitHub.
+		Forge    Forge
+		Owner    string
+		Repo     string
+		Releases []Release
+		// Token makes the repository private: API requests and asset
+		// downloads without it get 404 Not Found, like on the real forges.
+		Token string
+		// RateLimit is the number of API requests answered before the
+		// server responds with a rate limit error. Zero means unlimited.
+		RateLimit int
+		// RedirectAssets makes asset downloads redirect to another path,
+		// like GitHub redirecting to its CDN.
+		RedirectAssets bool
+		// PerPage is the page size of release lists. Defaults to 30.
+		PerPage int
+	}
+
+	Release struct {
+		Tag         string
+		Name        string
+		Body        string
+		Draft       bool
+		Prerelease  bool
+		PublishedAt time.Time
+		Assets      []Asset
+	}
+
+	Asset struct {
+		Name    string
+		Content []byte
+		// Size overrides len(Content) in API responses.
+		Size int
+	}
+
+	Server struct {
+		*httptest.Server
+
+		config Config
+
+		mu        sync.Mutex
+		remaining int
+		requests  map[string]int
+		failures  []*failure
+	}
+
+	failure struct {
+		prefix string
+		status int
+		times  int
+	}
+
+	apiRelease struct {
+		TagName    string     `json:"tag_name"`
+		Name       string     `json:"name"`
+		Body       string     `json:"body"`
+		URL        string     `json:"html_url"`
+		Published  time.Time  `json:"published_at"`
+		Draft      bool       `json:"draft"`
+		Prerelease bool       `json:"prerelease"`
+		Assets     []apiAsset `json:"assets"`
+	}
+
+	apiAsset struct {
+		ID          int    `json:"id"`
+		Name        string `json:"name"`
+		Size        int    `json:"size"`
+		URL         string `json:"url,omitempty"`
+		DownloadURL string `json:"browser_download_url"`
+	}
+)
+
+const (
+	GitHub Forge = "GitHub"
+	Gitea  Forge = "Gitea"
+
+	// rateLimitReset is the fixed reset time reported with rate limits.
+	rateLimitReset = 1700000000
+)
+
+// NewServer starts a fake forge. The caller must Close it.
+func NewServer(config Config) *Server {
+	config.Forge = cmp.Or(config.Forge, GitHub)
+	config.PerPage = cmp.Or(config.PerPage, 30)
+
+	s := &Server{
+		config:    config,
+		remaining: config.RateLimit,
+		requests:  make(map[string]int),
+	}
+	s.Server = httptest.NewServer(http.HandlerFunc(s.serveHTTP))
+
+	return s
+}
+
+// APIBaseURL is the value for Config.APIBaseURL of the updater.
+func (s *Server) APIBaseURL() string {
+	if s.config.Forge == Gitea {
+		return s.URL + "/api/v1"
+	}
+
+	return s.URL
+}
+
+// AddRelease publishes a release.
+func (s *Server) AddRelease(r Release) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.config.Releases = append(s.config.Releases, r)
+}
+
+// Fail makes the next times requests whose path starts with prefix fail
+// with status.
+func (s *Server) Fail(prefix string, status int, times int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.failures = append(s.failures, &failure{prefix: prefix, status: status, times: times})
+}
+
+// Requests returns the number of requests received for path.
+func (s *Server) Requests(path string) int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.requests[path]
+}
+
+// AssetURL returns the public download URL of an asset.
+func (s *Server) AssetURL(tag, name string) string {
+	return fmt.Sprintf("%s/%s/%s/releases/download/%s/%s", s.URL, s.config.Owner, s.config.Repo, tag, name)
+}
+
+// PageURL returns the web page of a release.
+func (s *Server) PageURL(tag string) string {
+	return fmt.Sprintf("%s/%s/%s/releases/tag/%s", s.URL, s.config.Owner, s.config.Repo, tag)
+}
+
+func (s *Server) serveHTTP(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	s.requests[r.URL.Path]++
+
+	for _, f := range s.failures {
+		if f.times > 0 && strings.HasPrefix(r.URL.Path, f.prefix) {
+			f.times--
+			s.mu.Unlock()
+			http.Error(w, http.StatusText(f.status), f.status)
+			return
+		}
+	}
+	s.mu.Unlock()
+
+	repo := "/" + s.config.Owner + "/" + s.config.Repo
+	apiRepo := strings.TrimPrefix(s.APIBaseURL(), s.URL) + "/repos" + repo
+
+	switch path := r.URL.Path; {
+	case strings.HasPrefix(path, apiRepo+"/releases"):
+		s.serveAPI(w, r, strings.TrimPrefix(path, apiRepo+"/releases"))
+	case strings.HasPrefix(path, repo+"/releases/download/"):
+		tag, name, _ := strings.Cut(strings.TrimPrefix(path, repo+"/releases/download/"), "/")
+		s.serveDownload(w, r, tag, name)
+	case strings.HasPrefix(path, "/cdn/"):
+		tag, name, _ := strings.Cut(strings.TrimPrefix(path, "/cdn/"), "/")
+		s.serveAsset(w, r, tag, name)
+	default:
+		http.NotFound(w, r)
+	}
+}
+
+func (s *Server) serveAPI(w http.ResponseWriter, r *http.Request, path string) {
+	if !s.authorized(r) {
+		http.NotFound(w, r)
+		return
+	}
+
+	if !s.checkQuota(w) {
+		return
+	}
+
+	switch {
+	case path == "" || path == "/":
+		s.serveList(w, r)
+	case path == "/latest":
+		rel, ok := s.latest()
+		if !ok {
+			http.NotFound(w, r)
+			return
+		}
+		s.writeJSON(w, r, s.apiRelease(rel))
+	case strings.HasPrefix(path, "/tags/"):
+		rel, ok := s.release(strings.TrimPrefix(path, "/tags/"))
+		if !ok {
+			http.NotFound(w, r)
+			return
+		}
+		s.writeJSON(w, r, s.apiRelease(rel))
+	case strings.HasPrefix(path, "/assets/"):
+		s.serveAssetByID(w, r, strings.TrimPrefix(path, "/assets/"))
+	default:
+		http.NotFound(w, r)
+	}
+}
+
+func (s *Server) serveList(w http.ResponseWriter, r *http.Request) {
+	perPage := s.config.PerPage
+	if v, err := strconv.Atoi(cmp.Or(r.URL.Query().Get("per_page"), r.URL.Query().Get("limit"))); err == nil && v > 0 {
+		perPage = min(v, s.config.PerPage)
+	}
+
+	page, err := strconv.Atoi(r.URL.Query().Get("page"))
+	if err != nil || page < 1 {
+		page = 1
+	}
+
+	releases := s.releases()
+	start := min((page-1)*perPage, len(releases))
+	end := min(start+perPage, len(releases))
+
+	if end < len(releases) {
+		next := *r.URL
+		q := next.Query()
+		q.Set("page", strconv.Itoa(page+1))
+		next.RawQuery = q.Encode()
+		w.Header().Set("Link", fmt.Sprintf(`<%s%s>; rel="next"`, s.URL, next.RequestURI()))
+	}
+
+	list := make([]apiRelease, 0, end-start)
+	for _, rel := range releases[start:end] {
+		list = append(list, s.apiRelease(rel))
+	}
+
+	s.writeJSON(w, r, list)
+}
+
+func (s *Server) serveDownload(w http.ResponseWriter, r *http.Request, tag, name string) {
+	// GitHub only serves assets of private repositories through the API.
+	if s.config.Token != "" && (s.config.Forge == GitHub || !s.authorized(r)) {
+		http.NotFound(w, r)
+		return
+	}
+
+	if s.config.RedirectAssets {
+		http.Redirect(w, r, s.URL+"/cdn/"+tag+"/"+name, http.StatusFound)
+		return
+	}
+
+	s.serveAsset(w, r, tag, name)
+}
+
+func (s *Server) serveAssetByID(w http.ResponseWriter, r *http.Request, id string) {
+	if r.Header.Get("Accept") != "application/octet-stream" {
+		http.Error(w, "Accept must be application/octet-stream", http.StatusUnsupportedMediaType)
+		return
+	}
+
+	for ri, rel := range s.releases() {
+		for ai, asset := range rel.Assets {
+			if id != strconv.Itoa(assetID(ri, ai)) {
+				continue
+			}
+
+			if s.config.RedirectAssets {
+				http.Redirect(w, r, s.URL+"/cdn/"+rel.Tag+"/"+asset.Name, http.StatusFound)
+				return
+			}
+
+			s.serveAsset(w, r, rel.Tag, asset.Name)
+			return
+		}
+	}
+
+	http.NotFound(w, r)
+}
+
+// serveAsset supports Range and If-Range requests through
+// http.ServeContent.
+func (s *Server) serveAsset(w http.ResponseWriter, r *http.Request, tag, name string) {
+	rel, ok := s.release(tag)
+	if !ok {
+		http.NotFound(w, r)
+		return
+	}
+
+	for _, asset := range rel.Assets {
+		if asset.Name == name {
+			w.Header().Set("ETag", contentETag(asset.Content))
+			http.ServeContent(w, r, name, rel.PublishedAt, strings.NewReader(string(asset.Content)))
+			return
+		}
+	}
+
+	http.NotFound(w, r)
+}
+
+func (s *Server) authorized(r *http.Request) bool {
+	if s.config.Token == "" {
+		return true
+	}
+
+	auth := r.Header.Get("Authorization")
+
+	return auth == "Bearer "+s.config.Token || auth == "token "+s.config.Token
+}
+
+// checkQuota writes the rate limit error once the quota is exhausted.
+func (s *Server) checkQuota(w http.ResponseWriter) bool {
+	if s.config.RateLimit == 0 {
+		return true
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	w.Header().Set("X-RateLimit-Limit", strconv.Itoa(s.config.RateLimit))
+	w.Header().Set("X-RateLimit-Reset", strconv.Itoa(rateLimitReset))
+
+	if s.remaining == 0 {
+		w.Header().Set("X-RateLimit-Remaining", "0")
+		http.Error(w, "API rate limit exceeded", http.StatusForbidden)
+		return false
+	}
+
+	return true
+}
+
+// spendQuota counts a response against the rate limit. Like on GitHub,
+// 304 Not Modified responses are free.
+func (s *Server) spendQuota(w http.ResponseWriter, notModified bool) {
+	if s.config.RateLimit == 0 {
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if !notModified {
+		s.remaining--
+	}
+	w.Header().Set("X-RateLimit-Remaining", strconv.Itoa(s.remaining))
+}
+
+func contentETag(content []byte) string {
+	sum := sha256.Sum256(content)
+	return `"` + hex.EncodeToString(sum[:8]) + `"`
+}
+
+// writeJSON answers conditional requests with 304 Not Modified.
+func (s *Server) writeJSON(w http.ResponseWriter, r *http.Request, v any) {
+	body, err := json.Marshal(v)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	etag := contentETag(body)
+	w.Header().Set("ETag", etag)
+
+	notModified := r.Header.Get("If-None-Match") == etag
+	s.spendQuota(w, notModified)
+
+	if notModified {
+		w.WriteHeader(http.StatusNotModified)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	_, _ = w.Write(body)
+}
+
+func (s *Server) releases() []Release {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return slices.Clone(s.config.Releases)
+}
+
+func (s *Server) release(tag string) (Release, bool) {
+	for _, rel := range s.releases() {
+		if rel.Tag == tag {
+			return rel, true
+		}
+	}
+
+	return Release{}, false
+}
+
+// latest returns the highest version that is neither a draft nor a
+// prerelease.
+func (s *Server) latest() (Release, bool) {
+	var (
+		best    Release
+		bestVer semver.Version
+		found   bool
+	)
+
+	for _, rel := range s.releases() {
+		if rel.Draft || rel.Prerelease {
+			continue
+		}
+
+		v, err := semver.Parse(strings.TrimPrefix(rel.Tag, "v"))
+		if err != nil {
+			continue
+		}
+
+		if !found || v.GT(bestVer) {
+			best, bestVer, found = rel, v, true
+		}
+	}
+
+	return best, found
+}
+
+func (s *Server) apiRelease(rel Release) apiRelease {
+	result := apiRelease{
+		TagName:    rel.Tag,
+		Name:       cmp.Or(rel.Name, rel.Tag),
+		Body:       rel.Body,
+		URL:        s.PageURL(rel.Tag),
+		Published:  rel.PublishedAt,
+		Draft:      rel.Draft,
+		Prerelease: rel.Prerelease,
+		Assets:     []apiAsset{},
+	}
+
+	ri := slices.IndexFunc(s.releases(), func(r Release) bool { return r.Tag == rel.Tag })
+	for ai, asset := range rel.Assets {
+		a := apiAsset{
+			ID:          assetID(ri, ai),
+			Name:        asset.Name,
+			Size:        cmp.Or(asset.Size, len(asset.Content)),
+			DownloadURL: s.AssetURL(rel.Tag, asset.Name),
+		}
+
+		if s.config.Forge == GitHub {
+			a.URL = fmt.Sprintf("%s/repos/%s/%s/releases/assets/%d", s.URL, s.config.Owner, s.config.Repo, a.ID)
+		}
+
+		result.Assets = append(result.Assets, a)
+	}
+
+	return result
+}
+
+func assetID(release, asset int) int {
+	return (release+1)*1000 + asset
+}
@@ -0,0 +1,107 @@
+package selfupdate
+
+import (
+	"bytes"
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/pem"
+	"errors"
+	"fmt"
+
+	"github.com/aatumaykin/go-self-update/selfupdate/release"
+	"github.com/inconshreveable/go-update"
+)
+
+// parsePublicKey accepts a PEM encoded PKIX public key (Ed25519, ECDSA or
+// RSA) or a raw Ed25519 key, either as 32 bytes or base64 encoded.
+func parsePublicKey(data []byte) (crypto.PublicKey, error) {
+	if block, _ := pem.Decode(data); block != nil {
+		pub, err := x509.ParsePKIXPublicKey(block.Bytes)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse public key: %w", err)
+		}
+
+		return pub, nil
+	}
+
+	if len(data) == ed25519.PublicKeySize {
+		return ed25519.PublicKey(data), nil
+	}
+
+	raw, err := base64.StdEncoding.DecodeString(string(bytes.TrimSpace(data)))
+	if err == nil && len(raw) == ed25519.PublicKeySize {
+		return ed25519.PublicKey(raw), nil
+	}
+
+	return nil, errors.New("failed to parse public key: expected PEM or raw ed25519 key")
+}
+
+// findSignatureAsset returns the detached signature asset of the release.
+func (u *Updater) findSignatureAsset(r release.Release, rel *Release) (release.Asset, error) {
+	name, err := renderTemplate(u.signatureTemplate, u.assetValues(rel))
+	if err != nil {
+		return nil, err
+	}
+
+	return findNamedAsset(r, name), nil
+}
+
+// verifySignature checks the detached signature of the downloaded asset
+// against its SHA-256 digest. Like go-update, the signature is made over the
+// digest rather than over the content.
+func (u *Updater) verifySignature(ctx context.Context, rel *Release, digest []byte) error {
+	if u.publicKey == nil {
+		return nil
+	}
+
+	var err error
+
+	sig := rel.Signature
+	if sig == nil {
+		if rel.SignatureURL == "" {
+			return fmt.Errorf("%w: no signature asset for %s", ErrSignatureMissing, rel.AssetName)
+		}
+
+		if sig, err = u.fetch(ctx, rel.SignatureURL); err != nil {
+			return fmt.Errorf("failed to download signature: %w", err)
+		}
+	}
+
+	sig = decodeSignature(sig)
+
+	switch pub := u.publicKey.(type) {
+	case ed25519.PublicKey:
+		if !ed25519.Verify(pub, digest, sig) {
+			err = errors.New("failed to verify ed25519 signature")
+		}
+	case *ecdsa.PublicKey:
+		err = update.NewECDSAVerifier().VerifySignature(digest, sig, crypto.SHA256, pub)
+	case *rsa.PublicKey:
+		err = update.NewRSAVerifier().VerifySignature(digest, sig, crypto.SHA256, pub)
+	default:
+		err = fmt.Errorf("unsupported public key type %T", pub)
+	}
+
+	if err != nil {
+		return fmt.Errorf("%w: %s: %w", ErrSignatureInvalid, rel.AssetName, err)
+	}
+
+	u.logger.InfoContext(ctx, "Signature verified", "asset", rel.AssetName)
+
+	return nil
+}
+
+// decodeSignature returns the raw signature of a binary or base64 encoded
+// signature file.
+func decodeSignature(data []byte) []byte {
+	if raw, err := base64.StdEncoding.DecodeString(string(bytes.TrimSpace(data))); err == nil {
+		return raw
+	}
+
+	return data
+}
@@ -0,0 +1,129 @@
+package selfupdate
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/pem"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/inconshreveable/go-update"
+)
+
+func TestUpdater_UpdateTo_Signature(t *testing.T) {
+	binary := []byte("new binary")
+	digest := sha256.Sum256(binary)
+
+	edPub, edPriv, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ecPriv, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ecDER, err := x509.MarshalPKIXPublicKey(&ecPriv.PublicKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ecPEM := pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: ecDER})
+	ecSig, err := ecdsa.SignASN1(rand.Reader, ecPriv, digest[:])
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	otherPub, _, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name      string
+		publicKey []byte
+		signature []byte
+		noURL     bool
+		wantErr   error
+	}{
+		{
+			name:      "raw ed25519 key with binary signature",
+			publicKey: edPub,
+			signature: ed25519.Sign(edPriv, digest[:]),
+		},
+		{
+			name:      "base64 ed25519 key with base64 signature",
+			publicKey: []byte(base64.StdEncoding.EncodeToString(edPub)),
+			signature: []byte(base64.StdEncoding.EncodeToString(ed25519.Sign(edPriv, digest[:])) + "\n"),
+		},
+		{
+			name:      "ECDSA PEM key",
+			publicKey: ecPEM,
+			signature: ecSig,
+		},
+		{
+			name:      "wrong key should fail",
+			publicKey: otherPub,
+			signature: ed25519.Sign(edPriv, digest[:]),
+			wantErr:   ErrSignatureInvalid,
+		},
+		{
+			name:      "missing signature should fail",
+			publicKey: edPub,
+			noURL:     true,
+			wantErr:   ErrSignatureMissing,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mux := http.NewServeMux()
+			mux.HandleFunc("/test", func(w http.ResponseWriter, r *http.Request) {
+				_, _ = w.Write(binary)
+			})
+			mux.HandleFunc("/test.sig", func(w http.ResponseWriter, r *http.Request) {
+				_, _ = w.Write(tt.signature)
+			})
+			srv := httptest.NewServer(mux)
+			defer srv.Close()
+
+			target := filepath.Join(t.TempDir(), "test")
+			if err := os.WriteFile(target, []byte("old binary"), 0o755); err != nil {
+				t.Fatal(err)
+			}
+
+			rel := &Release{AssetName: "test", AssetURL: srv.URL + "/test"}
+			if !tt.noURL {
+				rel.SignatureURL = srv.URL + "/test.sig"
+			}
+
+			u, err := New(Config{PublicKey: tt.publicKey})
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			err = u.UpdateTo(context.Background(), rel, &update.Options{TargetPath: target})
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("UpdateTo() error = %v, wantErr %v", err, tt.wantErr)
+			}
+
+			want := "new binary"
+			if tt.wantErr != nil {
+				want = "old binary"
+			}
+
+			got, _ := os.ReadFile(target)
+			if string(got) != want {
+				t.Errorf("UpdateTo() target = %q, want %q", got, want)
+			}
+		})
+	}
+}
@@ -0,0 +1,59 @@
+package selfupdate
+
+import (
+	"cmp"
+	"log/slog"
+	"net/http"
+)
+
+type (
+	// RequestHook is called for every outgoing request, including API
+	// calls, asset downloads and redirects, e.g. to add headers.
+	RequestHook func(req *http.Request) error
+
+	hookTransport struct {
+		base      http.RoundTripper
+		userAgent string
+		hooks     []RequestHook
+	}
+)
+
+const defaultUserAgent = "go-self-update"
+
+// newHTTPClient returns a copy of client whose transport sets the User-Agent,
+// runs the hooks and retries transient failures. The caller's client is left
+// untouched.
+func newHTTPClient(client *http.Client, userAgent string, hooks []RequestHook, retry *Retry, logger *slog.Logger) *http.Client {
+	c := *client
+	c.Transport = &hookTransport{
+		base: &retryTransport{
+			base:   &fileTransport{base: cmp.Or(client.Transport, http.DefaultTransport)},
+			retry:  retry,
+			logger: logger,
+		},
+		userAgent: userAgent,
+		hooks:     hooks,
+	}
+
+	return &c
+}
+
+func (t *hookTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	req = req.Clone(req.Context())
+
+	if req.Header.Get("User-Agent") == "" {
+		req.Header.Set("User-Agent", t.userAgent)
+	}
+
+	for _, hook := range t.hooks {
+		if err := hook(req); err != nil {
+			if req.Body != nil {
+				_ = req.Body.Close()
+			}
+
+			return nil, err
+		}
+	}
+
+	return t.base.RoundTrip(req)
+}
@@ -0,0 +1,101 @@
+package selfupdate
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+
+	"github.com/inconshreveable/go-update"
+)
+
+type recordingTransport struct {
+	mu       sync.Mutex
+	requests []*http.Request
+}
+
+func (t *recordingTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	t.mu.Lock()
+	t.requests = append(t.requests, req)
+	t.mu.Unlock()
+
+	return http.DefaultTransport.RoundTrip(req)
+}
+
+func TestUpdater_HTTPClient(t *testing.T) {
+	var srv *httptest.Server
+	mux := http.NewServeMux()
+	mux.HandleFunc("/repos/owner/repo/releases/latest", func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(`{
+			"tag_name": "1.0.0",
+			"assets": [{"name": "test-linux-amd64", "browser_download_url": "` + srv.URL + `/test-linux-amd64"}]
+		}`))
+	})
+	mux.HandleFunc("/test-linux-amd64", func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte("new binary"))
+	})
+	srv = httptest.NewServer(mux)
+	defer srv.Close()
+
+	transport := &recordingTransport{}
+	client := &http.Client{Transport: transport}
+
+	u, _ := New(Config{
+		HTTPClient: client,
+		UserAgent:  "test-agent",
+		RequestHooks: []RequestHook{
+			func(req *http.Request) error {
+				req.Header.Set("X-Test", "hook")
+				return nil
+			},
+		},
+		APIBaseURL: srv.URL,
+		Owner:      "owner",
+		Repo:       "repo",
+		Filter: &Filter{
+			Template: "{{.Name}}-{{.OS}}-{{.Arch}}",
+			Values:   map[string]string{"Name": "test", "OS": "linux", "Arch": "amd64"},
+		},
+	})
+
+	ctx := context.Background()
+	r, err := u.CheckVersion(ctx, "")
+	if err != nil {
+		t.Fatalf("CheckVersion() error = %v", err)
+	}
+
+	target := filepath.Join(t.TempDir(), "test")
+	if err := os.WriteFile(target, []byte("old binary"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := u.UpdateTo(ctx, r, &update.Options{TargetPath: target}); err != nil {
+		t.Fatalf("UpdateTo() error = %v", err)
+	}
+
+	if client.Transport != transport {
+		t.Errorf("New() modified the caller's HTTP client")
+	}
+
+	wantPaths := []string{"/repos/owner/repo/releases/latest", "/test-linux-amd64"}
+	if len(transport.requests) != len(wantPaths) {
+		t.Fatalf("got %d requests through the custom transport, want %d", len(transport.requests), len(wantPaths))
+	}
+
+	for i, req := range transport.requests {
+		if req.URL.Path != wantPaths[i] {
+			t.Errorf("request %d path = %v, want %v", i, req.URL.Path, wantPaths[i])
+		}
+
+		if got := req.Header.Get("User-Agent"); got != "test-agent" {
+			t.Errorf("request %d User-Agent = %v, want test-agent", i, got)
+		}
+
+		if got := req.Header.Get("X-Test"); got != "hook" {
+			t.Errorf("request %d X-Test = %v, want hook", i, got)
+		}
+	}
+}
@@ -3,52 +3,100 @@ package selfupdate
 import (
 	"cmp"
 	"context"
+	"crypto"
 	"fmt"
+	"io"
 	"log/slog"
+	"maps"
 	"net/http"
 	"os"
+	"path/filepath"
 	"runtime"
 	"strings"
 	"text/template"
 	"time"
 
-	"github.com/aatumaykin/go-self-update/selfupdate/gitea"
-	"github.com/aatumaykin/go-self-update/selfupdate/github"
 	"github.com/aatumaykin/go-self-update/selfupdate/release"
 	"github.com/blang/semver"
 	"github.com/inconshreveable/go-update"
 )
 
 type (
-	repoClient interface {
-		GetVersionUrl(version string) string
-		GetRelease(ctx context.Context, version string) (release.Release, error)
-	}
-
 	RepositoryType string
 
+	TokenSource = release.TokenSource
+	StaticToken = release.StaticToken
+
 	Release struct {
 		Version       semver.Version
+		TagName       string
+		Prerelease    bool
+		AssetName     string
 		AssetURL      string
 		AssetByteSize int
+		ChecksumURL   string
+		SignatureURL  string
 		PageURL       string
 		ReleaseNotes  string
 		Name          string
 		PublishedAt   time.Time
+		// Checksum and Signature are set when the repository lists them
+		// with the asset, e.g. in a manifest, instead of as separate assets.
+		Checksum  []byte
+		Signature []byte
+		// MatchedRule is the filter rule that selected the asset.
+		MatchedRule FilterRule
+		// PatchURL is the binary patch from the running version, if
+		// Config.Delta is set and the release has one.
+		PatchURL string
+
+		source release.Release
+		values map[string]string
 	}
 
 	Filter struct {
 		Template string
-		Values   map[string]string
+		// Values are available in all name templates: the filter, checksum,
+		// signature, delta, executable and Target.Asset templates. Name
+		// defaults to the base name of the executable, Version to the
+		// release tag as published (e.g. "v1.2.0"), and OS and Arch to the
+		// aliases of the running platform. Tag (the release tag) and SemVer
+		// (the version without prefix, e.g. "1.2.0") are always set.
+		Values map[string]string
+		// Rules are tried in order after Template until one matches an
+		// asset of the release.
+		Rules []FilterRule
+		// Prefer breaks ties between assets matched by the same rule: an
+		// asset whose name contains an earlier entry (case-insensitive),
+		// e.g. ".tar.gz" or "static", wins.
+		Prefer []string
 	}
 	Updater struct {
-		httpClient     *http.Client
-		logger         *slog.Logger
-		repositoryType RepositoryType
-		apiBaseURL     string
-		owner          string
-		repo           string
-		filter         *Filter
+		httpClient        *http.Client
+		logger            *slog.Logger
+		repositoryType    RepositoryType
+		apiBaseURL        string
+		owner             string
+		repo              string
+		packageName       string
+		filter            *Filter
+		currentVersion    *semver.Version
+		allowDowngrade    bool
+		checksum          *Checksum
+		executable        string
+		publicKey         crypto.PublicKey
+		signatureTemplate string
+		tokenSource       TokenSource
+		selector          *selector
+		progress          ProgressFunc
+		stagingDir        string
+		validation        *Validation
+		delta             *Delta
+		cacheDir          string
+		notice            *Notice
+		provider          Provider
+		providerErr       error
+		targetPath        string
 	}
 
 	Config struct {
@@ -59,137 +107,461 @@ type (
 		APIBaseURL     string
 		Owner          string
 		Repo           string
+		// UserAgent is sent with every request. Defaults to "go-self-update".
+		UserAgent string
+		// RequestHooks are called for every outgoing request made through
+		// HTTPClient: release metadata, assets, checksums and signatures.
+		RequestHooks []RequestHook
+		// PackageName is the GitLab generic package used as a fallback
+		// location for assets that are not attached as release links.
+		PackageName string
+		// CurrentVersion is the version of the running binary. If empty, the
+		// main module version from the build info is used.
+		CurrentVersion string
+		// AllowDowngrade lets UpdateTo install a release older than
+		// CurrentVersion.
+		AllowDowngrade bool
+		// Checksum configures lookup of the checksums asset. By default
+		// checksums.txt, SHA256SUMS and <asset>.sha256 are tried and
+		// verification is skipped if none of them exists.
+		Checksum *Checksum
+		// Executable is the path or template of the executable inside
+		// archive assets, e.g. "bin/{{.Name}}". Defaults to "{{.Name}}".
+		Executable string
+		// PublicKey enables verification of detached signatures. It accepts
+		// a PEM encoded Ed25519, ECDSA or RSA public key or a raw Ed25519
+		// key. The signature is made over the SHA-256 digest of the asset.
+		PublicKey []byte
+		// SignatureTemplate is the name template of the signature asset.
+		// Defaults to "{{.Asset}}.sig".
+		SignatureTemplate string
+		// Token authenticates API requests and asset downloads, e.g. for
+		// private repositories. If both Token and TokenSource are empty,
+		// GITHUB_TOKEN, GITEA_TOKEN or GITLAB_TOKEN is used depending on
+		// RepositoryType.
+		Token       string
+		TokenSource TokenSource
+		// Constraint restricts "latest" to releases matching a semver range,
+		// e.g. ">=1.2 <2.0".
+		Constraint string
+		// Prerelease lets "latest" resolve to prereleases.
+		Prerelease bool
+		// Channel lets "latest" resolve to prereleases whose first prerelease
+		// identifier starts with one of the identifiers of the channel.
+		Channel string
+		// Channels overrides DefaultChannels.
+		Channels map[string][]string
+		// Progress is called periodically while the asset is downloaded.
+		Progress ProgressFunc
+		// StagingDir holds partial downloads so that an interrupted download
+		// is resumed instead of restarted. Defaults to a directory in
+		// os.TempDir().
+		StagingDir string
+		// Validation checks the new executable after it is installed and
+		// restores the previous one if the check fails.
+		Validation *Validation
+		// CacheDir stores GitHub API responses with their ETags so that
+		// repeated checks are revalidated with conditional requests, which
+		// do not count against the rate limit.
+		CacheDir string
+		// Notice configures CheckInBackground.
+		Notice *Notice
+		// Source is a custom release provider used instead of
+		// RepositoryType.
+		Source Provider
+		// Delta enables binary patch updates.
+		Delta *Delta
+		// TargetPath is the file replaced by UpdateTo and DryRun when
+		// update.Options.TargetPath is empty. Defaults to the running
+		// executable.
+		TargetPath string
+		// Retry configures retries of requests made through HTTPClient
+		// after network errors and transient server errors. Defaults to 3
+		// attempts with exponential backoff.
+		Retry *Retry
 	}
 )
 
 const (
 	Github RepositoryType = "GitHub"
 	Gitea  RepositoryType = "Gitea"
+	GitLab RepositoryType = "GitLab"
+	// Manifest reads releases from a JSON manifest on a static file host,
+	// see the manifest package for the format.
+	Manifest RepositoryType = "Manifest"
+	// Local reads releases from a directory, e.g. on an air-gapped
+	// machine. APIBaseURL is the directory path or file:// URL.
+	Local RepositoryType = "Local"
 
 	latest = "latest"
 )
 
+var tokenEnv = map[RepositoryType]string{
+	Github: "GITHUB_TOKEN",
+	Gitea:  "GITEA_TOKEN",
+	GitLab: "GITLAB_TOKEN",
+}
+
 func New(config Config) (*Updater, error) {
-	return &Updater{
-		httpClient:     cmp.Or(config.HTTPClient, http.DefaultClient),
-		repositoryType: cmp.Or(config.RepositoryType, Github),
-		apiBaseURL:     config.APIBaseURL,
-		logger:         cmp.Or(config.Logger, slog.Default()),
-		owner:          config.Owner,
-		repo:           config.Repo,
+	checksum := cmp.Or(config.Checksum, &Checksum{})
+	if len(checksum.Templates) == 0 {
+		checksum = &Checksum{
+			Templates: defaultChecksumTemplates,
+			Required:  checksum.Required,
+		}
+	}
+
+	selector, err := newSelector(config)
+	if err != nil {
+		return nil, err
+	}
+
+	var publicKey crypto.PublicKey
+	if len(config.PublicKey) > 0 {
+		if publicKey, err = parsePublicKey(config.PublicKey); err != nil {
+			return nil, err
+		}
+	}
+
+	repositoryType := cmp.Or(config.RepositoryType, Github)
+
+	tokenSource := config.TokenSource
+	if tokenSource == nil {
+		if token := cmp.Or(config.Token, os.Getenv(tokenEnv[repositoryType])); token != "" {
+			tokenSource = StaticToken(token)
+		}
+	}
+
+	logger := cmp.Or(config.Logger, slog.Default())
+
+	u := &Updater{
+		httpClient: newHTTPClient(
+			cmp.Or(config.HTTPClient, http.DefaultClient),
+			cmp.Or(config.UserAgent, defaultUserAgent),
+			config.RequestHooks,
+			newRetry(config.Retry),
+			logger,
+		),
+		repositoryType:    repositoryType,
+		apiBaseURL:        config.APIBaseURL,
+		logger:            logger,
+		owner:             config.Owner,
+		repo:              config.Repo,
+		packageName:       config.PackageName,
+		currentVersion:    resolveCurrentVersion(config.CurrentVersion),
+		allowDowngrade:    config.AllowDowngrade,
+		checksum:          checksum,
+		executable:        cmp.Or(config.Executable, "{{.Name}}"),
+		publicKey:         publicKey,
+		signatureTemplate: cmp.Or(config.SignatureTemplate, "{{.Asset}}.sig"),
+		tokenSource:       tokenSource,
+		selector:          selector,
+		progress:          config.Progress,
+		validation:        config.Validation,
+		targetPath:        config.TargetPath,
+		delta:             newDelta(config.Delta),
+		stagingDir:        cmp.Or(config.StagingDir, filepath.Join(os.TempDir(), "go-self-update")),
 		filter: cmp.Or(config.Filter, &Filter{
 			Template: "{{.Name}}-{{.Version}}-{{.OS}}-{{.Arch}}",
 			Values:   make(map[string]string),
 		}),
-	}, nil
+		cacheDir: config.CacheDir,
+		notice:   config.Notice,
+	}
+
+	// The provider is kept for the lifetime of the Updater so that it can
+	// track the API rate limit.
+	if config.Source != nil {
+		u.provider = config.Source
+	} else {
+		u.provider, u.providerErr = u.newProvider()
+	}
+
+	return u, nil
+}
+
+// RateLimit returns the API quota reported by the last request, if the
+// repository reports one.
+func (u *Updater) RateLimit() (RateLimit, bool) {
+	rl, ok := u.provider.(interface {
+		RateLimit() (release.RateLimit, bool)
+	})
+	if !ok {
+		return RateLimit{}, false
+	}
+
+	return rl.RateLimit()
 }
 
 func (u *Updater) CheckVersion(ctx context.Context, version string) (*Release, error) {
 	version = cmp.Or(version, latest)
 
-	filter, err := u.getAssetNamePattern(version)
+	provider, err := u.provider, u.providerErr
 	if err != nil {
 		return nil, err
 	}
 
-	var rc repoClient
-
-	switch u.repositoryType {
-	case Github:
-		rc = github.New(github.Config{
-			APIBaseURL: u.apiBaseURL,
-			Filter:     filter,
-			Owner:      u.owner,
-			Repo:       u.repo,
-		})
-	case Gitea:
-		rc = gitea.New(gitea.Config{
-			APIBaseURL: u.apiBaseURL,
-			Filter:     filter,
-			Owner:      u.owner,
-			Repo:       u.repo,
-		})
-	default:
-		return nil, fmt.Errorf("unsupported repository type: %s", u.repositoryType)
-	}
-
-	r, err := rc.GetRelease(ctx, version)
+	var (
+		r     release.Release
+		match *assetMatch
+	)
+
+	if version == latest && u.selector != nil {
+		r, match, err = u.selectRelease(ctx, provider)
+	} else {
+		r, match, err = u.getRelease(ctx, provider, version)
+	}
+
 	if err != nil {
 		return nil, err
 	}
 
-	asset, found := r.FindAsset(filter)
-	if !found {
-		return nil, fmt.Errorf("asset not found")
+	return u.newRelease(r, match)
+}
+
+func (u *Updater) getRelease(ctx context.Context, provider Provider, version string) (release.Release, *assetMatch, error) {
+	var (
+		r   release.Release
+		err error
+	)
+
+	if version == latest {
+		r, err = provider.LatestRelease(ctx)
+	} else {
+		r, err = provider.ReleaseByTag(ctx, version)
+	}
+	if err != nil {
+		return nil, nil, err
 	}
 
+	match, err := u.findAsset(r)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return r, match, nil
+}
+
+func (u *Updater) newRelease(r release.Release, match *assetMatch) (*Release, error) {
+	version := r.GetVersion()
+	asset := match.asset
+
 	result := &Release{
-		Version:       r.GetVersion(),
+		Version:       version,
+		TagName:       r.GetTagName(),
+		Prerelease:    len(version.Pre) > 0 || r.IsPrerelease(),
 		Name:          r.GetName(),
 		PageURL:       r.GetPageURL(),
 		ReleaseNotes:  r.GetReleaseNotes(),
+		AssetName:     asset.GetName(),
 		AssetURL:      asset.GetDownloadURL(),
 		AssetByteSize: asset.GetSize(),
 		PublishedAt:   r.GetPublishedAt(),
+		MatchedRule:   match.rule,
+		source:        r,
+		values:        match.values,
+	}
+
+	if a, ok := asset.(release.ChecksumAsset); ok {
+		result.Checksum = a.GetSHA256()
+	}
+
+	if a, ok := asset.(release.SignedAsset); ok {
+		result.Signature = a.GetSignature()
+	}
+
+	if result.Checksum == nil {
+		checksumAsset, err := u.findChecksumAsset(r, result)
+		if err != nil {
+			return nil, err
+		}
+
+		if checksumAsset != nil {
+			result.ChecksumURL = checksumAsset.GetDownloadURL()
+		}
+	}
+
+	if u.delta != nil {
+		patchAsset, err := u.findPatchAsset(r, result)
+		if err != nil {
+			return nil, err
+		}
+
+		if patchAsset != nil {
+			result.PatchURL = patchAsset.GetDownloadURL()
+		}
+	}
+
+	if u.publicKey != nil && result.Signature == nil {
+		signatureAsset, err := u.findSignatureAsset(r, result)
+		if err != nil {
+			return nil, err
+		}
+
+		if signatureAsset != nil {
+			result.SignatureURL = signatureAsset.GetDownloadURL()
+		}
 	}
 
 	return result, nil
 }
 
 func (u *Updater) UpdateTo(ctx context.Context, rel *Release, updateOpts *update.Options) error {
-	u.logger.InfoContext(ctx, "Downloading", "url", rel.AssetURL, "size", rel.AssetByteSize)
+	if err := u.checkDowngrade(rel); err != nil {
+		return err
+	}
 
-	req, err := http.NewRequestWithContext(ctx, http.MethodGet, rel.AssetURL, nil)
-	if err != nil {
-		return fmt.Errorf("failed to create HTTP request: %w", err)
+	opts := update.Options{}
+	if updateOpts != nil {
+		opts = *updateOpts
 	}
-	req.Header.Add("Accept", "application/json")
 
-	resp, err := u.httpClient.Do(req)
+	var err error
+	if opts.TargetPath, err = u.resolveTarget(opts.TargetPath); err != nil {
+		return err
+	}
+
+	p, err := u.prepare(ctx, rel, opts.TargetPath, u.executable, false)
 	if err != nil {
-		return fmt.Errorf("failed to send HTTP request: %w", err)
+		return err
+	}
+	defer p.Close()
+
+	// A checksum passed by the caller is kept unless the release has one.
+	if p.checksum != nil && p.entry == rel.AssetName {
+		opts.Checksum = p.checksum
 	}
-	defer resp.Body.Close()
 
-	if updateOpts == nil {
-		updateOpts = &update.Options{}
+	// Keep the previous executable until the new one is validated.
+	removeOld := false
+	if u.validation != nil && opts.OldSavePath == "" {
+		opts.OldSavePath = oldSavePath(opts.TargetPath)
+		removeOld = true
 	}
 
 	u.logger.InfoContext(ctx, "Applying update")
-	err = update.Apply(resp.Body, *updateOpts)
+	err = update.Apply(p, opts)
 	if err != nil {
+		if rerr := update.RollbackError(err); rerr != nil {
+			return fmt.Errorf("failed to apply update: %w; rollback failed: %w", err, rerr)
+		}
+
 		return fmt.Errorf("failed to apply update: %w", err)
 	}
 
+	if u.validation != nil {
+		if err := u.validate(ctx, opts.TargetPath, opts.OldSavePath); err != nil {
+			return err
+		}
+
+		if removeOld {
+			_ = os.Remove(opts.OldSavePath)
+		}
+	}
+
 	u.logger.InfoContext(ctx, "Update applied")
 
 	return nil
 }
 
-func (u *Updater) getAssetNamePattern(version string) (string, error) {
-	tpl, err := template.New("name").Parse(u.filter.Template)
+// newRequest creates an authorized GET request for a release asset.
+func (u *Updater) newRequest(ctx context.Context, url string) (*http.Request, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
 	if err != nil {
-		return "", err
+		return nil, fmt.Errorf("failed to create HTTP request: %w", err)
+	}
+	req.Header.Add("Accept", "application/octet-stream")
+
+	provider, err := u.provider, u.providerErr
+	if err != nil {
+		return nil, err
 	}
 
-	if _, ok := u.filter.Values["Name"]; !ok {
-		u.filter.Values["Name"] = os.Args[0]
+	if err := provider.Authorize(ctx, req); err != nil {
+		return nil, err
 	}
 
-	if _, ok := u.filter.Values["OS"]; !ok {
-		u.filter.Values["OS"] = runtime.GOOS
+	return req, nil
+}
+
+func (u *Updater) fetch(ctx context.Context, url string) ([]byte, error) {
+	req, err := u.newRequest(ctx, url)
+	if err != nil {
+		return nil, err
 	}
 
-	if _, ok := u.filter.Values["Arch"]; !ok {
-		u.filter.Values["Arch"] = runtime.GOARCH
+	resp, err := u.httpClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to send HTTP request: %w", err)
 	}
+	defer resp.Body.Close()
 
-	if _, ok := u.filter.Values["Version"]; !ok {
-		u.filter.Values["Version"] = version
+	if err := release.CheckResponse(resp); err != nil {
+		return nil, err
+	}
+
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to GET %v: %w", url, err)
+	}
+
+	return data, nil
+}
+
+// templateValues returns the values of all name templates for a release,
+// see Filter.Values. findAsset replaces OS and Arch with each alias.
+func (u *Updater) templateValues(tag string, version semver.Version) map[string]string {
+	data := make(map[string]string, len(u.filter.Values)+6)
+	for k, v := range u.filter.Values {
+		data[k] = v
+	}
+
+	data["Name"] = filepath.Base(cmp.Or(data["Name"], os.Args[0]))
+	data["Version"] = cmp.Or(data["Version"], tag)
+	data["OS"] = cmp.Or(data["OS"], runtime.GOOS)
+	data["Arch"] = cmp.Or(data["Arch"], runtime.GOARCH)
+	data["Tag"] = tag
+	data["SemVer"] = version.String()
+
+	return data
+}
+
+// assetValues returns the template values the asset of rel was matched
+// with, including OS and Arch, completed with Asset (the asset name).
+func (u *Updater) assetValues(rel *Release) map[string]string {
+	data := maps.Clone(rel.values)
+	if data == nil {
+		data = u.templateValues(rel.TagName, rel.Version)
+	}
+
+	data["Asset"] = rel.AssetName
+
+	return data
+}
+
+// resolveTarget returns path, Config.TargetPath or the running executable,
+// whichever is set first.
+func (u *Updater) resolveTarget(path string) (string, error) {
+	if path = cmp.Or(path, u.targetPath); path != "" {
+		return path, nil
+	}
+
+	path, err := os.Executable()
+	if err != nil {
+		return "", fmt.Errorf("failed to locate executable: %w", err)
+	}
+
+	return path, nil
+}
+
+func renderTemplate(text string, data map[string]string) (string, error) {
+	tpl, err := template.New("name").Parse(text)
+	if err != nil {
+		return "", err
 	}
 
 	var buf strings.Builder
-	err = tpl.Execute(&buf, u.filter.Values)
+	err = tpl.Execute(&buf, data)
 
 	return buf.String(), err
 }
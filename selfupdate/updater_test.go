@@ -2,361 +2,563 @@ package selfupdate
 
 import (
 	"context"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
 	"testing"
 	"time"
 
+	"github.com/aatumaykin/go-self-update/selfupdate/selfupdatetest"
 	"github.com/blang/semver"
+	"github.com/inconshreveable/go-update"
 )
 
+// testRepository mirrors the releases of the test repositories once used
+// by these tests on github.com and gitea.com.
+func testRepository(forge selfupdatetest.Forge, owner string, published time.Time, notes string) selfupdatetest.Config {
+	return selfupdatetest.Config{
+		Forge: forge,
+		Owner: owner,
+		Repo:  "test-repository",
+		Releases: []selfupdatetest.Release{{
+			Tag:         "1.0.0",
+			Body:        notes,
+			PublishedAt: published,
+			Assets: []selfupdatetest.Asset{
+				{Name: "test-darwin-amd64", Size: 2026240},
+				{Name: "test-darwin-arm64", Size: 2029586},
+				{Name: "test-linux-amd64", Size: 1897953},
+				{Name: "test-linux-arm64", Size: 1946270},
+			},
+		}},
+	}
+}
+
 func TestUpdater_CheckVersion(t *testing.T) {
+	github := testRepository(selfupdatetest.GitHub, "aatumaykin", time.Date(2024, 4, 27, 9, 54, 23, 0, time.UTC), "test release")
+	gitea := testRepository(selfupdatetest.Gitea, "tumaykin", time.Date(2024, 4, 26, 22, 45, 00, 0, time.UTC), "")
+
 	tests := []struct {
-		name    string
-		config  Config
-		slug    string
-		version string
-		want    *Release
-		wantErr bool
+		name           string
+		repositoryType RepositoryType
+		server         selfupdatetest.Config
+		os             string
+		arch           string
+		version        string
+		want           *Release
+		wantErr        bool
 	}{
 		{
-			name: "gitea: latest should return test-darwin-arm64",
-			config: Config{
-				RepositoryType: Gitea,
-				Owner:          "tumaykin",
-				Repo:           "test-repository",
-				Filter: &Filter{
-					Template: "{{.Name}}-{{.OS}}-{{.Arch}}",
-					Values: map[string]string{
-						"Name": "test",
-						"OS":   "darwin",
-						"Arch": "arm64",
-					},
-				},
-			},
-			version: "",
-			want: &Release{
-				Version: func() semver.Version {
-					v, _ := semver.New("1.0.0")
-					return *v
-				}(),
-				Name:          "1.0.0",
-				PageURL:       "https://gitea.com/tumaykin/test-repository/releases/tag/1.0.0",
-				AssetURL:      "https://gitea.com/tumaykin/test-repository/releases/download/1.0.0/test-darwin-arm64",
-				AssetByteSize: 2029586,
-				PublishedAt:   time.Date(2024, 4, 26, 22, 45, 00, 0, time.UTC),
-			},
-			wantErr: false,
+			name:           "gitea: latest should return test-darwin-arm64",
+			repositoryType: Gitea,
+			server:         gitea,
+			os:             "darwin",
+			arch:           "arm64",
+			want:           &Release{AssetName: "test-darwin-arm64", AssetByteSize: 2029586},
 		},
 		{
-			name: "gitea: latest should return test-darwin-amd64",
-			config: Config{
-				RepositoryType: Gitea,
-				Owner:          "tumaykin",
-				Repo:           "test-repository",
-				Filter: &Filter{
-					Template: "{{.Name}}-{{.OS}}-{{.Arch}}",
-					Values: map[string]string{
-						"Name": "test",
-						"OS":   "darwin",
-						"Arch": "amd64",
-					},
-				},
-			},
-			version: "",
-			want: &Release{
-				Version: func() semver.Version {
-					v, _ := semver.New("1.0.0")
-					return *v
-				}(),
-				Name:          "1.0.0",
-				PageURL:       "https://gitea.com/tumaykin/test-repository/releases/tag/1.0.0",
-				AssetURL:      "https://gitea.com/tumaykin/test-repository/releases/download/1.0.0/test-darwin-amd64",
-				AssetByteSize: 2026240,
-				PublishedAt:   time.Date(2024, 4, 26, 22, 45, 00, 0, time.UTC),
-			},
-			wantErr: false,
+			name:           "gitea: latest should return test-darwin-amd64",
+			repositoryType: Gitea,
+			server:         gitea,
+			os:             "darwin",
+			arch:           "amd64",
+			want:           &Release{AssetName: "test-darwin-amd64", AssetByteSize: 2026240},
 		},
 		{
-			name: "gitea: latest should return test-linux-amd64",
-			config: Config{
-				RepositoryType: Gitea,
-				Owner:          "tumaykin",
-				Repo:           "test-repository",
-				Filter: &Filter{
-					Template: "{{.Name}}-{{.OS}}-{{.Arch}}",
-					Values: map[string]string{
-						"Name": "test",
-						"OS":   "linux",
-						"Arch": "amd64",
-					},
-				},
-			},
-			version: "",
-			want: &Release{
-				Version: func() semver.Version {
-					v, _ := semver.New("1.0.0")
-					return *v
-				}(),
-				Name:          "1.0.0",
-				PageURL:       "https://gitea.com/tumaykin/test-repository/releases/tag/1.0.0",
-				AssetURL:      "https://gitea.com/tumaykin/test-repository/releases/download/1.0.0/test-linux-amd64",
-				AssetByteSize: 1897953,
-				PublishedAt:   time.Date(2024, 4, 26, 22, 45, 00, 0, time.UTC),
-			},
-			wantErr: false,
+			name:           "gitea: latest should return test-linux-amd64",
+			repositoryType: Gitea,
+			server:         gitea,
+			os:             "linux",
+			arch:           "amd64",
+			want:           &Release{AssetName: "test-linux-amd64", AssetByteSize: 1897953},
 		},
 		{
-			name: "gitea: latest should return test-linux-arm64",
-			config: Config{
-				RepositoryType: Gitea,
-				Owner:          "tumaykin",
-				Repo:           "test-repository",
-				Filter: &Filter{
-					Template: "{{.Name}}-{{.OS}}-{{.Arch}}",
-					Values: map[string]string{
-						"Name": "test",
-						"OS":   "linux",
-						"Arch": "arm64",
-					},
-				},
-			},
-			version: "",
-			want: &Release{
-				Version: func() semver.Version {
-					v, _ := semver.New("1.0.0")
-					return *v
-				}(),
-				Name:          "1.0.0",
-				PageURL:       "https://gitea.com/tumaykin/test-repository/releases/tag/1.0.0",
-				AssetURL:      "https://gitea.com/tumaykin/test-repository/releases/download/1.0.0/test-linux-arm64",
-				AssetByteSize: 1946270,
-				PublishedAt:   time.Date(2024, 4, 26, 22, 45, 00, 0, time.UTC),
-			},
-			wantErr: false,
+			name:           "gitea: latest should return test-linux-arm64",
+			repositoryType: Gitea,
+			server:         gitea,
+			os:             "linux",
+			arch:           "arm64",
+			want:           &Release{AssetName: "test-linux-arm64", AssetByteSize: 1946270},
 		},
 		{
-			name: "gitea: 1.0.0 should return test-darwin-arm64",
-			config: Config{
-				RepositoryType: Gitea,
-				Owner:          "tumaykin",
-				Repo:           "test-repository",
-				Filter: &Filter{
-					Template: "{{.Name}}-{{.OS}}-{{.Arch}}",
-					Values: map[string]string{
-						"Name": "test",
-						"OS":   "darwin",
-						"Arch": "arm64",
-					},
-				},
-			},
-			version: "1.0.0",
-			want: &Release{
-				Version: func() semver.Version {
-					v, _ := semver.New("1.0.0")
-					return *v
-				}(),
-				Name:          "1.0.0",
-				PageURL:       "https://gitea.com/tumaykin/test-repository/releases/tag/1.0.0",
-				AssetURL:      "https://gitea.com/tumaykin/test-repository/releases/download/1.0.0/test-darwin-arm64",
-				AssetByteSize: 2029586,
-				PublishedAt:   time.Date(2024, 4, 26, 22, 45, 00, 0, time.UTC),
-			},
-			wantErr: false,
+			name:           "gitea: 1.0.0 should return test-darwin-arm64",
+			repositoryType: Gitea,
+			server:         gitea,
+			os:             "darwin",
+			arch:           "arm64",
+			version:        "1.0.0",
+			want:           &Release{AssetName: "test-darwin-arm64", AssetByteSize: 2029586},
 		},
 		{
-			name: "github: latest should return test-darwin-arm64",
-			config: Config{
-				RepositoryType: Github,
-				Owner:          "aatumaykin",
-				Repo:           "test-repository",
-				Filter: &Filter{
-					Template: "{{.Name}}-{{.OS}}-{{.Arch}}",
-					Values: map[string]string{
-						"Name": "test",
-						"OS":   "darwin",
-						"Arch": "arm64",
-					},
-				},
-			},
-			version: "",
-			want: &Release{
-				Version: func() semver.Version {
-					v, _ := semver.New("1.0.0")
-					return *v
-				}(),
-				Name:          "1.0.0",
-				PageURL:       "https://github.com/aatumaykin/test-repository/releases/tag/1.0.0",
-				AssetURL:      "https://github.com/aatumaykin/test-repository/releases/download/1.0.0/test-darwin-arm64",
-				AssetByteSize: 2029586,
-				PublishedAt:   time.Date(2024, 4, 27, 9, 54, 23, 0, time.UTC),
-				ReleaseNotes:  "test release",
-			},
-			wantErr: false,
+			name:           "github: latest should return test-darwin-arm64",
+			repositoryType: Github,
+			server:         github,
+			os:             "darwin",
+			arch:           "arm64",
+			want:           &Release{AssetName: "test-darwin-arm64", AssetByteSize: 2029586},
 		},
 		{
-			name: "github: latest should return test-darwin-amd64",
-			config: Config{
-				RepositoryType: Github,
-				Owner:          "aatumaykin",
-				Repo:           "test-repository",
-				Filter: &Filter{
-					Template: "{{.Name}}-{{.OS}}-{{.Arch}}",
-					Values: map[string]string{
-						"Name": "test",
-						"OS":   "darwin",
-						"Arch": "amd64",
-					},
-				},
-			},
-			version: "",
-			want: &Release{
-				Version: func() semver.Version {
-					v, _ := semver.New("1.0.0")
-					return *v
-				}(),
-				Name:          "1.0.0",
-				PageURL:       "https://github.com/aatumaykin/test-repository/releases/tag/1.0.0",
-				AssetURL:      "https://github.com/aatumaykin/test-repository/releases/download/1.0.0/test-darwin-amd64",
-				AssetByteSize: 2026240,
-				PublishedAt:   time.Date(2024, 4, 27, 9, 54, 23, 0, time.UTC),
-				ReleaseNotes:  "test release",
-			},
-			wantErr: false,
+			name:           "github: latest should return test-darwin-amd64",
+			repositoryType: Github,
+			server:         github,
+			os:             "darwin",
+			arch:           "amd64",
+			want:           &Release{AssetName: "test-darwin-amd64", AssetByteSize: 2026240},
 		},
 		{
-			name: "github: latest should return test-linux-amd64",
-			config: Config{
-				RepositoryType: Github,
-				Owner:          "aatumaykin",
-				Repo:           "test-repository",
-				Filter: &Filter{
-					Template: "{{.Name}}-{{.OS}}-{{.Arch}}",
-					Values: map[string]string{
-						"Name": "test",
-						"OS":   "linux",
-						"Arch": "amd64",
-					},
-				},
-			},
-			version: "",
-			want: &Release{
-				Version: func() semver.Version {
-					v, _ := semver.New("1.0.0")
-					return *v
-				}(),
-				Name:          "1.0.0",
-				PageURL:       "https://github.com/aatumaykin/test-repository/releases/tag/1.0.0",
-				AssetURL:      "https://github.com/aatumaykin/test-repository/releases/download/1.0.0/test-linux-amd64",
-				AssetByteSize: 1897953,
-				PublishedAt:   time.Date(2024, 4, 27, 9, 54, 23, 0, time.UTC),
-				ReleaseNotes:  "test release",
-			},
-			wantErr: false,
+			name:           "github: latest should return test-linux-amd64",
+			repositoryType: Github,
+			server:         github,
+			os:             "linux",
+			arch:           "amd64",
+			want:           &Release{AssetName: "test-linux-amd64", AssetByteSize: 1897953},
 		},
 		{
-			name: "github: latest should return test-linux-arm64",
-			config: Config{
-				RepositoryType: Github,
-				Owner:          "aatumaykin",
-				Repo:           "test-repository",
+			name:           "github: latest should return test-linux-arm64",
+			repositoryType: Github,
+			server:         github,
+			os:             "linux",
+			arch:           "arm64",
+			want:           &Release{AssetName: "test-linux-arm64", AssetByteSize: 1946270},
+		},
+		{
+			name:           "github: 1.0.0 should return test-linux-arm64",
+			repositoryType: Github,
+			server:         github,
+			os:             "linux",
+			arch:           "arm64",
+			version:        "1.0.0",
+			want:           &Release{AssetName: "test-linux-arm64", AssetByteSize: 1946270},
+		},
+		{
+			name:           "github: unknown version should fail",
+			repositoryType: Github,
+			server:         github,
+			os:             "linux",
+			arch:           "arm64",
+			version:        "2.0.0",
+			wantErr:        true,
+		},
+		{
+			name:           "github: unknown platform should fail",
+			repositoryType: Github,
+			server:         github,
+			os:             "plan9",
+			arch:           "mips",
+			wantErr:        true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			srv := selfupdatetest.NewServer(tt.server)
+			defer srv.Close()
+
+			u, _ := New(Config{
+				RepositoryType: tt.repositoryType,
+				APIBaseURL:     srv.APIBaseURL(),
+				Owner:          tt.server.Owner,
+				Repo:           tt.server.Repo,
 				Filter: &Filter{
 					Template: "{{.Name}}-{{.OS}}-{{.Arch}}",
 					Values: map[string]string{
 						"Name": "test",
-						"OS":   "linux",
-						"Arch": "arm64",
+						"OS":   tt.os,
+						"Arch": tt.arch,
 					},
 				},
-			},
-			version: "",
-			want: &Release{
-				Version: func() semver.Version {
-					v, _ := semver.New("1.0.0")
-					return *v
-				}(),
-				Name:          "1.0.0",
-				PageURL:       "https://github.com/aatumaykin/test-repository/releases/tag/1.0.0",
-				AssetURL:      "https://github.com/aatumaykin/test-repository/releases/download/1.0.0/test-linux-arm64",
-				AssetByteSize: 1946270,
-				PublishedAt:   time.Date(2024, 4, 27, 9, 54, 23, 0, time.UTC),
-				ReleaseNotes:  "test release",
-			},
-			wantErr: false,
+			})
+			ctx := context.Background()
+			r, err := u.CheckVersion(ctx, tt.version)
+
+			if (err != nil) != tt.wantErr {
+				t.Errorf("CheckVersion() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+
+			if tt.want == nil {
+				return
+			}
+
+			release := tt.server.Releases[0]
+			want := tt.want
+			want.Version = semver.MustParse("1.0.0")
+			want.Name = release.Tag
+			want.ReleaseNotes = release.Body
+			want.PublishedAt = release.PublishedAt
+			want.PageURL = srv.PageURL(release.Tag)
+			want.AssetURL = srv.AssetURL(release.Tag, want.AssetName)
+
+			if r.Name != want.Name || r.ReleaseNotes != want.ReleaseNotes || r.PageURL != want.PageURL {
+				t.Errorf("CheckVersion() got = %v, want %v", r, want)
+			}
+
+			if r.Version.String() != want.Version.String() || !r.PublishedAt.Equal(want.PublishedAt) {
+				t.Errorf("CheckVersion() got = %v, want %v", r, want)
+			}
+
+			if r.AssetName != want.AssetName || r.AssetURL != want.AssetURL || r.AssetByteSize != want.AssetByteSize {
+				t.Errorf("CheckVersion() got = %v, want %v", r, want)
+			}
+		})
+	}
+}
+
+func TestUpdater_CheckVersion_GitLab(t *testing.T) {
+	mux := http.NewServeMux()
+	srv := httptest.NewServer(mux)
+	defer srv.Close()
+
+	releaseJSON := `{
+		"tag_name": "v1.2.0",
+		"name": "1.2.0",
+		"description": "gitlab release",
+		"released_at": "2024-05-01T10:00:00Z",
+		"assets": {"links": [{
+			"id": 1,
+			"name": "test-linux-amd64",
+			"url": "https://example.com/test-linux-amd64",
+			"direct_asset_url": "` + "https://gitlab.example.com/group/test/-/releases/v1.2.0/downloads/test-linux-amd64" + `",
+			"link_type": "package"
+		}, {
+			"id": 2,
+			"name": "SHA256SUMS",
+			"url": "https://example.com/SHA256SUMS",
+			"direct_asset_url": "` + "https://gitlab.example.com/group/test/-/releases/v1.2.0/downloads/SHA256SUMS" + `",
+			"link_type": "other"
+		}]},
+		"_links": {"self": "https://gitlab.example.com/group/test/-/releases/v1.2.0"}
+	}`
+
+	mux.HandleFunc("/projects/group%2Ftest/releases/permalink/latest", func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(releaseJSON))
+	})
+	mux.HandleFunc("/projects/group%2Ftest/releases/v1.2.0", func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(releaseJSON))
+	})
+
+	tests := []struct {
+		name        string
+		version     string
+		packageName string
+		arch        string
+		wantURL     string
+		wantErr     bool
+	}{
+		{
+			name:    "latest should return release link",
+			arch:    "amd64",
+			wantURL: "https://gitlab.example.com/group/test/-/releases/v1.2.0/downloads/test-linux-amd64",
+		},
+		{
+			name:    "tag should return release link",
+			version: "v1.2.0",
+			arch:    "amd64",
+			wantURL: "https://gitlab.example.com/group/test/-/releases/v1.2.0/downloads/test-linux-amd64",
+		},
+		{
+			name:    "missing link without package should fail",
+			arch:    "arm64",
+			wantErr: true,
 		},
 		{
-			name: "github: 1.0.0 should return test-linux-arm64",
-			config: Config{
-				RepositoryType: Github,
-				Owner:          "aatumaykin",
-				Repo:           "test-repository",
+			name:        "missing link should fall back to generic package",
+			arch:        "arm64",
+			packageName: "test",
+			wantURL:     srv.URL + "/projects/group%2Ftest/packages/generic/test/v1.2.0/test-linux-arm64",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			u, _ := New(Config{
+				RepositoryType: GitLab,
+				APIBaseURL:     srv.URL,
+				Owner:          "group",
+				Repo:           "test",
+				PackageName:    tt.packageName,
 				Filter: &Filter{
 					Template: "{{.Name}}-{{.OS}}-{{.Arch}}",
 					Values: map[string]string{
 						"Name": "test",
 						"OS":   "linux",
-						"Arch": "arm64",
+						"Arch": tt.arch,
 					},
 				},
-			},
-			version: "1.0.0",
-			want: &Release{
-				Version: func() semver.Version {
-					v, _ := semver.New("1.0.0")
-					return *v
-				}(),
-				Name:          "1.0.0",
-				PageURL:       "https://github.com/aatumaykin/test-repository/releases/tag/1.0.0",
-				AssetURL:      "https://github.com/aatumaykin/test-repository/releases/download/1.0.0/test-linux-arm64",
-				AssetByteSize: 1946270,
-				PublishedAt:   time.Date(2024, 4, 27, 9, 54, 23, 0, time.UTC),
-				ReleaseNotes:  "test release",
-			},
-			wantErr: false,
+			})
+
+			r, err := u.CheckVersion(context.Background(), tt.version)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("CheckVersion() error = %v, wantErr %v", err, tt.wantErr)
+			}
+
+			if tt.wantErr {
+				return
+			}
+
+			if r.AssetURL != tt.wantURL {
+				t.Errorf("CheckVersion() AssetURL = %v, want %v", r.AssetURL, tt.wantURL)
+			}
+
+			// A listed checksums asset wins over a generic package guess.
+			if want := "https://gitlab.example.com/group/test/-/releases/v1.2.0/downloads/SHA256SUMS"; r.ChecksumURL != want {
+				t.Errorf("CheckVersion() ChecksumURL = %v, want %v", r.ChecksumURL, want)
+			}
+
+			if r.Version.String() != "1.2.0" {
+				t.Errorf("CheckVersion() Version = %v, want 1.2.0", r.Version)
+			}
+
+			if r.ReleaseNotes != "gitlab release" {
+				t.Errorf("CheckVersion() ReleaseNotes = %v", r.ReleaseNotes)
+			}
+		})
+	}
+}
+
+func TestUpdater_GitLab_TokenRedirect(t *testing.T) {
+	var cdnAuth, cdnToken string
+
+	cdn := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		cdnAuth, cdnToken = r.Header.Get("Authorization"), r.Header.Get("PRIVATE-TOKEN")
+		_, _ = w.Write([]byte("new binary"))
+	}))
+	defer cdn.Close()
+
+	// The CDN is addressed as localhost so that it is another host than the
+	// API, which listens on 127.0.0.1.
+	cdnURL := strings.Replace(cdn.URL, "127.0.0.1", "localhost", 1)
+
+	var apiAuth string
+
+	mux := http.NewServeMux()
+	srv := httptest.NewServer(mux)
+	defer srv.Close()
+
+	mux.HandleFunc("/projects/group%2Ftest/releases/permalink/latest", func(w http.ResponseWriter, r *http.Request) {
+		apiAuth = r.Header.Get("Authorization")
+		_, _ = w.Write([]byte(`{"tag_name": "v1.2.0", "assets": {"links": [{
+			"name": "test-linux-amd64",
+			"direct_asset_url": "` + srv.URL + `/group/test/-/releases/v1.2.0/downloads/test-linux-amd64"
+		}]}}`))
+	})
+	mux.HandleFunc("/group/test/-/releases/v1.2.0/downloads/test-linux-amd64", func(w http.ResponseWriter, r *http.Request) {
+		http.Redirect(w, r, cdnURL+"/test-linux-amd64", http.StatusFound)
+	})
+
+	u, _ := New(Config{
+		RepositoryType: GitLab,
+		APIBaseURL:     srv.URL,
+		Owner:          "group",
+		Repo:           "test",
+		Token:          "secret",
+		StagingDir:     t.TempDir(),
+		Filter: &Filter{
+			Template: "{{.Name}}-{{.OS}}-{{.Arch}}",
+			Values:   map[string]string{"Name": "test", "OS": "linux", "Arch": "amd64"},
+		},
+	})
+	ctx := context.Background()
+
+	r, err := u.CheckVersion(ctx, "")
+	if err != nil {
+		t.Fatalf("CheckVersion() error = %v", err)
+	}
+
+	target := filepath.Join(t.TempDir(), "test")
+	if err := os.WriteFile(target, []byte("old binary"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := u.UpdateTo(ctx, r, &update.Options{TargetPath: target}); err != nil {
+		t.Fatalf("UpdateTo() error = %v", err)
+	}
+
+	if apiAuth != "Bearer secret" {
+		t.Errorf("API Authorization = %q, want %q", apiAuth, "Bearer secret")
+	}
+
+	if cdnAuth != "" || cdnToken != "" {
+		t.Errorf("CDN received the token: Authorization = %q, PRIVATE-TOKEN = %q", cdnAuth, cdnToken)
+	}
+}
+
+func TestUpdater_PrivateRepository(t *testing.T) {
+	tests := []struct {
+		name           string
+		repositoryType RepositoryType
+		config         Config
+		env            map[string]string
+		wantErr        bool
+	}{
+		{
+			name:           "github: token from config",
+			repositoryType: Github,
+			config:         Config{Token: "secret"},
+		},
+		{
+			name:           "github: token from environment",
+			repositoryType: Github,
+			env:            map[string]string{"GITHUB_TOKEN": "secret"},
+		},
+		{
+			name:           "gitea: token source",
+			repositoryType: Gitea,
+			config:         Config{TokenSource: StaticToken("secret")},
+		},
+		{
+			name:           "github: missing token should fail",
+			repositoryType: Github,
+			wantErr:        true,
 		},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			u, _ := New(tt.config)
+			t.Setenv("GITHUB_TOKEN", "")
+			t.Setenv("GITEA_TOKEN", "")
+			for k, v := range tt.env {
+				t.Setenv(k, v)
+			}
+
+			forge := selfupdatetest.GitHub
+			if tt.repositoryType == Gitea {
+				forge = selfupdatetest.Gitea
+			}
+
+			srv := selfupdatetest.NewServer(selfupdatetest.Config{
+				Forge: forge,
+				Owner: "owner",
+				Repo:  "private",
+				Token: "secret",
+				Releases: []selfupdatetest.Release{{
+					Tag:    "1.0.0",
+					Assets: []selfupdatetest.Asset{{Name: "test-linux-amd64", Content: []byte("new binary")}},
+				}},
+			})
+			defer srv.Close()
+
+			config := tt.config
+			config.RepositoryType = tt.repositoryType
+			config.APIBaseURL = srv.APIBaseURL()
+			config.Owner = "owner"
+			config.Repo = "private"
+			config.Filter = &Filter{
+				Template: "{{.Name}}-{{.OS}}-{{.Arch}}",
+				Values:   map[string]string{"Name": "test", "OS": "linux", "Arch": "amd64"},
+			}
+
+			u, _ := New(config)
 			ctx := context.Background()
-			r, err := u.CheckVersion(ctx, tt.version)
 
+			r, err := u.CheckVersion(ctx, "")
 			if (err != nil) != tt.wantErr {
-				t.Errorf("CheckVersion() error = %v, wantErr %v", err, tt.wantErr)
+				t.Fatalf("CheckVersion() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err != nil {
 				return
 			}
 
-			if tt.want != nil {
-				if r == nil {
-					t.Errorf("CheckVersion() got = %v, want %v", r, tt.want)
-					return
-				}
+			target := filepath.Join(t.TempDir(), "test")
+			if err := os.WriteFile(target, []byte("old binary"), 0o755); err != nil {
+				t.Fatal(err)
+			}
 
-				if r.Name != tt.want.Name {
-					t.Errorf("CheckVersion() got = %v, want %v", r, tt.want)
-				}
+			if err := u.UpdateTo(ctx, r, &update.Options{TargetPath: target}); err != nil {
+				t.Fatalf("UpdateTo() error = %v", err)
+			}
 
-				if r.ReleaseNotes != tt.want.ReleaseNotes {
-					t.Errorf("CheckVersion() got = %v, want %v", r, tt.want)
-				}
+			got, _ := os.ReadFile(target)
+			if string(got) != "new binary" {
+				t.Errorf("UpdateTo() target = %q, want %q", got, "new binary")
+			}
+		})
+	}
+}
 
-				if r.PageURL != tt.want.PageURL {
-					t.Errorf("CheckVersion() got = %v, want %v", r, tt.want)
-				}
+func TestUpdater_UpdateTo_Forge(t *testing.T) {
+	tests := []struct {
+		name     string
+		redirect bool
+		fail     int
+		times    int
+		wantErr  bool
+	}{
+		{
+			name: "direct download",
+		},
+		{
+			name:     "redirected download",
+			redirect: true,
+		},
+		{
+			name:  "transient server error should be retried",
+			fail:  http.StatusBadGateway,
+			times: 1,
+		},
+		{
+			name:    "persistent server error should fail",
+			fail:    http.StatusInternalServerError,
+			times:   3,
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			srv := selfupdatetest.NewServer(selfupdatetest.Config{
+				Owner:          "owner",
+				Repo:           "repo",
+				RedirectAssets: tt.redirect,
+				Releases: []selfupdatetest.Release{{
+					Tag:    "1.0.0",
+					Assets: []selfupdatetest.Asset{{Name: "test-linux-amd64", Content: []byte("new binary")}},
+				}},
+			})
+			defer srv.Close()
+
+			if tt.fail != 0 {
+				srv.Fail("/owner/repo/releases/download/", tt.fail, tt.times)
+			}
 
-				if r.Version.String() != tt.want.Version.String() {
-					t.Errorf("CheckVersion() got = %v, want %v", r, tt.want)
-				}
+			u, _ := New(Config{
+				APIBaseURL: srv.APIBaseURL(),
+				Owner:      "owner",
+				Repo:       "repo",
+				StagingDir: t.TempDir(),
+				Retry:      &Retry{InitialBackoff: time.Millisecond},
+				Filter: &Filter{
+					Template: "{{.Name}}-{{.OS}}-{{.Arch}}",
+					Values:   map[string]string{"Name": "test", "OS": "linux", "Arch": "amd64"},
+				},
+			})
+			ctx := context.Background()
 
-				if r.AssetURL != tt.want.AssetURL {
-					t.Errorf("CheckVersion() got = %v, want %v", r, tt.want)
-				}
+			r, err := u.CheckVersion(ctx, "")
+			if err != nil {
+				t.Fatalf("CheckVersion() error = %v", err)
+			}
+
+			target := filepath.Join(t.TempDir(), "test")
+			if err := os.WriteFile(target, []byte("old binary"), 0o755); err != nil {
+				t.Fatal(err)
+			}
 
-				if r.AssetByteSize != tt.want.AssetByteSize {
-					t.Errorf("CheckVersion() got = %v, want %v", r, tt.want)
-				}
+			err = u.UpdateTo(ctx, r, &update.Options{TargetPath: target})
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("UpdateTo() error = %v, wantErr %v", err, tt.wantErr)
+			}
+
+			want := "new binary"
+			if tt.wantErr {
+				want = "old binary"
+			}
+
+			got, _ := os.ReadFile(target)
+			if string(got) != want {
+				t.Errorf("UpdateTo() target = %q, want %q", got, want)
+			}
 
-				if r.PublishedAt != tt.want.PublishedAt {
-					t.Errorf("CheckVersion() got = %v, want %v", r, tt.want)
-				}
+			if tt.redirect && srv.Requests("/cdn/1.0.0/test-linux-amd64") != 1 {
+				t.Error("UpdateTo() did not follow the redirect")
 			}
 		})
 	}
@@ -0,0 +1,114 @@
+package selfupdate
+
+import (
+	"cmp"
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+type (
+	// Validation checks the new executable right after it is installed. If
+	// the check fails, the previous executable is restored.
+	Validation struct {
+		// Args are passed to the new executable, e.g. []string{"--version"}.
+		// The executable is not run if both Args and Func are empty.
+		Args []string
+		// Timeout limits the run of the executable. Defaults to 10 seconds.
+		Timeout time.Duration
+		// ExpectedOutput must be contained in the combined output.
+		ExpectedOutput string
+		// ExpectedExitCode is the exit code of a healthy executable.
+		ExpectedExitCode int
+		// Func is an additional check run after the executable.
+		Func func(ctx context.Context, path string) error
+	}
+
+	// ValidationError is returned when the installed executable failed the
+	// validation. RollbackErr is set if the previous executable could not
+	// be restored.
+	ValidationError struct {
+		Err         error
+		RollbackErr error
+	}
+)
+
+const defaultValidationTimeout = 10 * time.Second
+
+func (e *ValidationError) Error() string {
+	if e.RollbackErr != nil {
+		return fmt.Sprintf("validation of new executable failed: %v; rollback failed: %v", e.Err, e.RollbackErr)
+	}
+
+	return fmt.Sprintf("validation of new executable failed, previous version restored: %v", e.Err)
+}
+
+func (e *ValidationError) Unwrap() []error {
+	return []error{e.Err, e.RollbackErr}
+}
+
+func (v *Validation) run(ctx context.Context, path string) error {
+	if len(v.Args) > 0 {
+		if err := v.exec(ctx, path); err != nil {
+			return err
+		}
+	}
+
+	if v.Func != nil {
+		return v.Func(ctx, path)
+	}
+
+	return nil
+}
+
+func (v *Validation) exec(ctx context.Context, path string) error {
+	ctx, cancel := context.WithTimeout(ctx, cmp.Or(v.Timeout, defaultValidationTimeout))
+	defer cancel()
+
+	out, err := exec.CommandContext(ctx, path, v.Args...).CombinedOutput()
+
+	code := 0
+	if err != nil {
+		var exitErr *exec.ExitError
+		if !errors.As(err, &exitErr) || ctx.Err() != nil {
+			return fmt.Errorf("failed to run %s: %w", path, cmp.Or(ctx.Err(), err))
+		}
+		code = exitErr.ExitCode()
+	}
+
+	if code != v.ExpectedExitCode {
+		return fmt.Errorf("%s exited with code %d, want %d: %s", path, code, v.ExpectedExitCode, strings.TrimSpace(string(out)))
+	}
+
+	if v.ExpectedOutput != "" && !strings.Contains(string(out), v.ExpectedOutput) {
+		return fmt.Errorf("output of %s does not contain %q: %s", path, v.ExpectedOutput, strings.TrimSpace(string(out)))
+	}
+
+	return nil
+}
+
+// validate runs the configured validation of the executable installed at
+// target and restores the previous executable from oldPath if it fails.
+func (u *Updater) validate(ctx context.Context, target, oldPath string) error {
+	u.logger.InfoContext(ctx, "Validating update", "path", target)
+
+	err := u.validation.run(ctx, target)
+	if err == nil {
+		return nil
+	}
+
+	u.logger.ErrorContext(ctx, "Validation failed, rolling back", "error", err)
+
+	return &ValidationError{Err: err, RollbackErr: os.Rename(oldPath, target)}
+}
+
+// oldSavePath returns where go-update keeps the previous executable when
+// OldSavePath is not set.
+func oldSavePath(target string) string {
+	return filepath.Join(filepath.Dir(target), fmt.Sprintf(".%s.old", filepath.Base(target)))
+}
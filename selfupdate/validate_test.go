@@ -0,0 +1,108 @@
+package selfupdate
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+
+	"github.com/inconshreveable/go-update"
+)
+
+func TestUpdater_UpdateTo_Validation(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("validation test uses shell scripts")
+	}
+
+	const (
+		oldScript = "#!/bin/sh\necho v1\n"
+		newScript = "#!/bin/sh\necho v2\nexit 0\n"
+		badScript = "#!/bin/sh\necho broken\nexit 3\n"
+	)
+
+	errCheck := errors.New("health check failed")
+
+	tests := []struct {
+		name       string
+		script     string
+		validation *Validation
+		want       string
+		wantErr    error
+	}{
+		{
+			name:       "healthy executable should be kept",
+			script:     newScript,
+			validation: &Validation{Args: []string{"--version"}, ExpectedOutput: "v2"},
+			want:       newScript,
+		},
+		{
+			name:       "wrong exit code should roll back",
+			script:     badScript,
+			validation: &Validation{Args: []string{"--version"}},
+			want:       oldScript,
+			wantErr:    &ValidationError{},
+		},
+		{
+			name:       "unexpected output should roll back",
+			script:     newScript,
+			validation: &Validation{Args: []string{"--version"}, ExpectedOutput: "v3"},
+			want:       oldScript,
+			wantErr:    &ValidationError{},
+		},
+		{
+			name:   "failing func should roll back",
+			script: newScript,
+			validation: &Validation{Func: func(ctx context.Context, path string) error {
+				return errCheck
+			}},
+			want:    oldScript,
+			wantErr: errCheck,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				_, _ = w.Write([]byte(tt.script))
+			}))
+			defer srv.Close()
+
+			dir := t.TempDir()
+			target := filepath.Join(dir, "test")
+			if err := os.WriteFile(target, []byte(oldScript), 0o755); err != nil {
+				t.Fatal(err)
+			}
+
+			u, _ := New(Config{StagingDir: t.TempDir(), Validation: tt.validation})
+			rel := &Release{AssetName: "test", AssetURL: srv.URL + "/test"}
+
+			err := u.UpdateTo(context.Background(), rel, &update.Options{TargetPath: target})
+			switch want := tt.wantErr.(type) {
+			case nil:
+				if err != nil {
+					t.Fatalf("UpdateTo() error = %v", err)
+				}
+			case *ValidationError:
+				if !errors.As(err, &want) || want.RollbackErr != nil {
+					t.Fatalf("UpdateTo() error = %v, want rolled back ValidationError", err)
+				}
+			default:
+				if !errors.Is(err, want) {
+					t.Fatalf("UpdateTo() error = %v, want %v", err, want)
+				}
+			}
+
+			got, _ := os.ReadFile(target)
+			if string(got) != tt.want {
+				t.Errorf("UpdateTo() target = %q, want %q", got, tt.want)
+			}
+
+			if _, err := os.Stat(oldSavePath(target)); !os.IsNotExist(err) {
+				t.Errorf("previous executable was left behind: %v", err)
+			}
+		})
+	}
+}
@@ -0,0 +1,113 @@
+package selfupdate
+
+import (
+	"context"
+	"fmt"
+	"runtime/debug"
+	"strings"
+
+	"github.com/blang/semver"
+)
+
+type (
+	UpdateStatus int
+
+	UpdateCheck struct {
+		Status  UpdateStatus
+		Current semver.Version
+		Latest  *Release
+	}
+)
+
+const (
+	UpdateAvailable UpdateStatus = iota + 1
+	UpToDate
+	NewerThanRemote
+)
+
+func (s UpdateStatus) String() string {
+	switch s {
+	case UpdateAvailable:
+		return "update available"
+	case UpToDate:
+		return "up to date"
+	case NewerThanRemote:
+		return "newer than remote"
+	default:
+		return fmt.Sprintf("UpdateStatus(%d)", int(s))
+	}
+}
+
+// DetectLatest returns the latest release without comparing it to the
+// running version.
+func (u *Updater) DetectLatest(ctx context.Context) (*Release, error) {
+	return u.CheckVersion(ctx, latest)
+}
+
+// CheckForUpdate compares the latest release with the running version.
+// The result is always returned when the comparison was made; the error is
+// ErrNoUpdateAvailable unless the status is UpdateAvailable.
+func (u *Updater) CheckForUpdate(ctx context.Context) (*UpdateCheck, error) {
+	current, ok := u.CurrentVersion()
+	if !ok {
+		return nil, ErrUnknownCurrentVersion
+	}
+
+	rel, err := u.DetectLatest(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	result := &UpdateCheck{
+		Current: current,
+		Latest:  rel,
+	}
+
+	switch rel.Version.Compare(current) {
+	case 1:
+		result.Status = UpdateAvailable
+		return result, nil
+	case 0:
+		result.Status = UpToDate
+	default:
+		result.Status = NewerThanRemote
+	}
+
+	return result, ErrNoUpdateAvailable
+}
+
+// CurrentVersion returns the version of the running binary taken from
+// Config.CurrentVersion or, if empty, from the main module build info.
+func (u *Updater) CurrentVersion() (semver.Version, bool) {
+	if u.currentVersion == nil {
+		return semver.Version{}, false
+	}
+
+	return *u.currentVersion, true
+}
+
+func (u *Updater) checkDowngrade(rel *Release) error {
+	current, ok := u.CurrentVersion()
+	if !ok || u.allowDowngrade || !rel.Version.LT(current) {
+		return nil
+	}
+
+	return fmt.Errorf("%w: %s is older than %s", ErrDowngrade, rel.Version, current)
+}
+
+func resolveCurrentVersion(version string) *semver.Version {
+	if version == "" {
+		info, ok := debug.ReadBuildInfo()
+		if !ok {
+			return nil
+		}
+		version = info.Main.Version
+	}
+
+	v, err := semver.Parse(strings.TrimPrefix(version, "v"))
+	if err != nil {
+		return nil
+	}
+
+	return &v
+}
@@ -0,0 +1,82 @@
+package selfupdate
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/blang/semver"
+)
+
+func TestUpdater_CheckForUpdate(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(`{
+			"tag_name": "v1.2.0",
+			"name": "1.2.0",
+			"assets": [{"name": "test-linux-amd64", "size": 10, "browser_download_url": "https://example.com/test-linux-amd64"}]
+		}`))
+	}))
+	defer srv.Close()
+
+	tests := []struct {
+		name       string
+		current    string
+		wantStatus UpdateStatus
+		wantErr    error
+	}{
+		{
+			name:       "older current version should report update",
+			current:    "1.1.9",
+			wantStatus: UpdateAvailable,
+		},
+		{
+			name:       "same version should report up to date",
+			current:    "v1.2.0",
+			wantStatus: UpToDate,
+			wantErr:    ErrNoUpdateAvailable,
+		},
+		{
+			name:       "newer current version should report newer than remote",
+			current:    "2.0.0-beta.1",
+			wantStatus: NewerThanRemote,
+			wantErr:    ErrNoUpdateAvailable,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			u, _ := New(Config{
+				RepositoryType: Github,
+				APIBaseURL:     srv.URL,
+				CurrentVersion: tt.current,
+				Filter: &Filter{
+					Template: "{{.Name}}-{{.OS}}-{{.Arch}}",
+					Values:   map[string]string{"Name": "test", "OS": "linux", "Arch": "amd64"},
+				},
+			})
+
+			got, err := u.CheckForUpdate(context.Background())
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("CheckForUpdate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+
+			if got.Status != tt.wantStatus {
+				t.Errorf("CheckForUpdate() status = %v, want %v", got.Status, tt.wantStatus)
+			}
+
+			if got.Latest.Version.String() != "1.2.0" {
+				t.Errorf("CheckForUpdate() latest = %v, want 1.2.0", got.Latest.Version)
+			}
+		})
+	}
+}
+
+func TestUpdater_UpdateTo_Downgrade(t *testing.T) {
+	u, _ := New(Config{CurrentVersion: "1.2.0"})
+
+	err := u.UpdateTo(context.Background(), &Release{Version: semver.MustParse("1.1.0")}, nil)
+	if !errors.Is(err, ErrDowngrade) {
+		t.Errorf("UpdateTo() error = %v, want %v", err, ErrDowngrade)
+	}
+}